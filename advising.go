@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// Prints the compre marks a student needs to reach a target total, given as EMPID:TARGET
+func printNeededForTarget(w io.Writer, students []Student, spec string) {
+	empID, rawTarget, ok := strings.Cut(spec, ":")
+	if !ok {
+		fatalf("Invalid --needed-for %q: expected EMPID:TARGET", spec)
+	}
+	target, err := strconv.ParseFloat(rawTarget, 64)
+	if err != nil {
+		fatalf("Invalid --needed-for target %q: %v", rawTarget, err)
+	}
+
+	student := findStudent(students, empID)
+
+	compre, _ := componentByKey("compre")
+	preCompre := preCompreTotal(*student)
+	needed := target - preCompre
+
+	fmt.Fprintf(w, "%s %s: pre-compre %.2f, target total %.2f\n", idLabel, empID, preCompre, target)
+	switch {
+	case needed <= 0:
+		fmt.Fprintln(w, "Target already reached without compre marks")
+	case needed > compre.max:
+		fmt.Fprintf(w, "Not achievable: needs %.2f on compre, maximum is %.2f\n", needed, compre.max)
+	default:
+		fmt.Fprintf(w, "Needs %.2f/%g on compre\n", needed, compre.max)
+	}
+}
+
+// Returns the student with the given EmpID, exiting when there is none
+func findStudent(students []Student, empID string) *Student {
+	for i := range students {
+		if students[i].EmpID == empID {
+			return &students[i]
+		}
+	}
+	fatalf("%s %s not found among valid students", idLabel, empID)
+	return nil
+}
+
+// Prints a student's full mark breakdown and how their total was derived
+func printExplanation(w io.Writer, students []Student, empID string) {
+	printBreakdown(w, *findStudent(students, empID))
+}
+
+// Prints n uniformly chosen students' breakdowns for spot checks; the same seed always
+// selects the same students from the same file
+func printSample(w io.Writer, students []Student, n int, seed uint64) {
+	rng := rand.New(rand.NewPCG(seed, seed))
+	picked := rng.Perm(len(students))[:min(n, len(students))]
+	fmt.Fprintf(w, "Sampled %d of %d students (seed %d)\n\n", len(picked), len(students), seed)
+	for i, idx := range picked {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		printBreakdown(w, students[idx])
+	}
+}
+
+// Prints the full mark breakdown of one student
+func printBreakdown(w io.Writer, s Student) {
+	fmt.Fprintln(w, "======================================")
+	fmt.Fprintf(w, "Breakdown for %s %s (%s, %s)\n", idLabel, s.EmpID, s.Branch, branchMap[s.Branch])
+	for _, comp := range components {
+		if comp.key == "total" {
+			continue
+		}
+		fmt.Fprintf(w, "%s: %.2f\n", comp.name(), comp.getVal(s))
+	}
+	if len(columns.CompreColumns) > 0 {
+		if s.CompreAttempt == 0 {
+			fmt.Fprintln(w, "Compre attempt used: none recorded")
+		} else {
+			fmt.Fprintf(w, "Compre attempt used: %d of %d (%s rule)\n", s.CompreAttempt, len(columns.CompreColumns), *compreRuleFlag)
+		}
+	}
+	fmt.Fprintf(w, "Pre-Compre: %.2f\n", preCompreTotal(s))
+	fmt.Fprintf(w, "Computed Total: %.2f\n", computedTotal(s))
+	fmt.Fprintf(w, "Sheet Total: %.2f\n", s.Total)
+}
+
+// Prints students who fall short of the next grade cutoff by at most margin, with the
+// marks they need and the component in which they trail the cohort the most
+func printAppealCandidates(w io.Writer, students []Student, cutoffs []gradeCutoff, margin float64) {
+	fmt.Fprintln(w, "\n"+bold("======================================"))
+	fmt.Fprintln(w, bold(fmt.Sprintf("Likely Grade Appeals (within %.2f marks below a cutoff)", margin)))
+
+	sorted := sortByComponent(students, func(s Student) float64 { return s.Total })
+	found := false
+	for _, s := range sorted {
+		next, ok := nextCutoff(s.Total, cutoffs)
+		if !ok || next.Min-s.Total > margin {
+			continue
+		}
+		weakest := weakestRelativeComponent(s)
+		stat := componentStats[weakest.key]
+		fmt.Fprintf(w, "%s: %s - %.2f, needs %.2f for %s; weakest against the cohort in %s (%.2f vs mean %.2f)\n",
+			idLabel, s.EmpID, s.Total, next.Min-s.Total, next.Grade, weakest.name(), weakest.getVal(s), stat.Mean)
+		found = true
+	}
+	if !found {
+		fmt.Fprintln(w, "No students within the margin")
+	}
+}
+
+// Returns the lowest cutoff above total, if any. Cutoffs are ordered highest first.
+func nextCutoff(total float64, cutoffs []gradeCutoff) (gradeCutoff, bool) {
+	for i := len(cutoffs) - 1; i >= 0; i-- {
+		if cutoffs[i].Min > total {
+			return cutoffs[i], true
+		}
+	}
+	return gradeCutoff{}, false
+}
+
+// Returns the component, excluding Total, with the student's lowest z-score
+func weakestRelativeComponent(s Student) component {
+	var weakest component
+	lowest := math.Inf(1)
+	for _, comp := range components {
+		if comp.key == "total" {
+			continue
+		}
+		if z := zScore(s, comp); z < lowest {
+			weakest, lowest = comp, z
+		}
+	}
+	return weakest
+}
+
+// Prints students whose mid-sem score, as a percentage of its maximum, fell more than
+// threshold percentage points below their quiz percentage, steepest decline first
+func printDecliners(w io.Writer, students []Student, threshold float64) {
+	fmt.Fprintln(w, "\n"+bold("======================================"))
+	fmt.Fprintln(w, bold(fmt.Sprintf("Students Declining from Quiz to Mid-Sem (more than %.2f points)", threshold)))
+
+	quiz, okQuiz := componentByKey("quiz")
+	midSem, okMidSem := componentByKey("midsem")
+	if !okQuiz || !okMidSem {
+		fmt.Fprintln(w, "Quiz and Mid-Sem must both be reported separately to compare them")
+		return
+	}
+
+	delta := func(s Student) float64 {
+		return 100*midSem.getVal(s)/midSem.max - 100*quiz.getVal(s)/quiz.max
+	}
+	sorted := sortByComponent(students, func(s Student) float64 { return -delta(s) })
+	found := false
+	for _, s := range sorted {
+		if d := delta(s); d < -threshold {
+			fmt.Fprintf(w, "%s: %s - Quiz %.2f%%, Mid-Sem %.2f%% (%+.2f points)\n",
+				idLabel, s.EmpID, 100*quiz.getVal(s)/quiz.max, 100*midSem.getVal(s)/midSem.max, d)
+			found = true
+		}
+	}
+	if !found {
+		fmt.Fprintln(w, "No students declined beyond the threshold")
+	}
+}
+
+// Minimum marks required in individual components from --component-pass, keyed by component key
+var componentPassMarks map[string]float64
+
+// Returns the components in which a student scored below the --component-pass minimum
+func failedComponents(s Student) []component {
+	var failed []component
+	for _, comp := range components {
+		if minimum, ok := componentPassMarks[comp.key]; ok && comp.getVal(s) < minimum {
+			failed = append(failed, comp)
+		}
+	}
+	return failed
+}
+
+// Prints students who fell below a component's minimum even though their Total passes,
+// or every student below a component minimum when no total pass mark is set
+func printComponentFailures(w io.Writer, students []Student) {
+	fmt.Fprintln(w, "\n"+bold("======================================"))
+	fmt.Fprintln(w, bold("Students Failing a Component"))
+
+	total, _ := componentByKey("total")
+	found := false
+	for _, s := range rankByComponent(students, total) {
+		if passEnabled() && s.Total < passThreshold(s.Branch) {
+			continue
+		}
+		failed := failedComponents(s)
+		if len(failed) == 0 {
+			continue
+		}
+		var parts []string
+		for _, comp := range failed {
+			parts = append(parts, fmt.Sprintf("%s %.2f < %.2f", comp.label, comp.getVal(s), componentPassMarks[comp.key]))
+		}
+		fmt.Fprintf(w, "%s: %s - Total %.2f; %s\n", idLabel, s.EmpID, s.Total, strings.Join(parts, ", "))
+		found = true
+	}
+	if !found {
+		fmt.Fprintln(w, "None")
+	}
+}
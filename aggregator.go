@@ -0,0 +1,108 @@
+package main
+
+import "math"
+
+// Accumulates branch sums, pass counts and per-component statistics one student at a
+// time, so statistics can be built from a stream without holding every student
+type Aggregator struct {
+	branchSums   map[string]float64
+	branchCounts map[string]int
+	branchPasses map[string]int
+	totalSum     float64
+	totalCount   int
+	totalPasses  int
+	components   map[string]*runningStat
+}
+
+// Statistics accumulated by an Aggregator
+type Aggregate struct {
+	BranchSums     map[string]float64
+	BranchCounts   map[string]int
+	BranchPasses   map[string]int
+	TotalSum       float64
+	TotalCount     int
+	TotalPasses    int
+	ComponentStats map[string]componentStat
+}
+
+// Running mean and variance of one component (Welford's method)
+type runningStat struct {
+	n    int
+	mean float64
+	m2   float64
+}
+
+// Creates an empty aggregator
+func newAggregator() *Aggregator {
+	a := &Aggregator{
+		branchSums:   make(map[string]float64),
+		branchCounts: make(map[string]int),
+		branchPasses: make(map[string]int),
+		components:   make(map[string]*runningStat),
+	}
+	for _, comp := range components {
+		a.components[comp.key] = &runningStat{}
+	}
+	return a
+}
+
+// Returns an aggregator fed with every student
+func aggregate(students []Student) *Aggregator {
+	a := newAggregator()
+	for _, s := range students {
+		a.Add(s)
+	}
+	return a
+}
+
+// Adds one accepted student to the running statistics
+func (a *Aggregator) Add(s Student) {
+	group := branchGroup(s.Branch)
+	a.branchSums[group] += s.Total
+	a.branchCounts[group]++
+	a.totalSum += s.Total
+	a.totalCount++
+
+	if passEnabled() && s.Total >= passThreshold(s.Branch) {
+		a.branchPasses[group]++
+		a.totalPasses++
+	}
+
+	for _, comp := range components {
+		a.components[comp.key].add(comp.getVal(s))
+	}
+}
+
+// Returns the statistics accumulated so far
+func (a *Aggregator) Result() Aggregate {
+	stats := make(map[string]componentStat, len(a.components))
+	for key, rs := range a.components {
+		stats[key] = rs.stat()
+	}
+	return Aggregate{
+		BranchSums:     a.branchSums,
+		BranchCounts:   a.branchCounts,
+		BranchPasses:   a.branchPasses,
+		TotalSum:       a.totalSum,
+		TotalCount:     a.totalCount,
+		TotalPasses:    a.totalPasses,
+		ComponentStats: stats,
+	}
+}
+
+// Folds one value into the running mean and variance
+func (rs *runningStat) add(v float64) {
+	rs.n++
+	delta := v - rs.mean
+	rs.mean += delta / float64(rs.n)
+	rs.m2 += delta * (v - rs.mean)
+}
+
+// Returns the mean and population standard deviation, NaN when no value was added,
+// matching mean and stddev over an empty slice
+func (rs *runningStat) stat() componentStat {
+	if rs.n == 0 {
+		return componentStat{Mean: math.NaN(), StdDev: math.NaN()}
+	}
+	return componentStat{Mean: rs.mean, StdDev: math.Sqrt(rs.m2 / float64(rs.n))}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAggregatorAdd(t *testing.T) {
+	tests := []struct {
+		name      string
+		pass      float64
+		students  []Student
+		sums      map[string]float64
+		passes    int
+		quizMean  float64
+		quizStdev float64
+	}{
+		{
+			name: "single branch",
+			students: []Student{
+				{Branch: "2024A7", Quiz: 10, Total: 200},
+				{Branch: "2024A7", Quiz: 20, Total: 100},
+			},
+			sums:      map[string]float64{"2024A7": 300},
+			quizMean:  15,
+			quizStdev: 5,
+		},
+		{
+			name: "pass counts",
+			pass: 150,
+			students: []Student{
+				{Branch: "2024A7", Quiz: 30, Total: 200},
+				{Branch: "2024A3", Quiz: 0, Total: 100},
+				{Branch: "2024A3", Quiz: 15, Total: 150},
+			},
+			sums:      map[string]float64{"2024A7": 200, "2024A3": 250},
+			passes:    2,
+			quizMean:  15,
+			quizStdev: math.Sqrt(150),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag(t, passFlag, tt.pass)
+			agg := newAggregator()
+			for _, s := range tt.students {
+				agg.Add(s)
+			}
+			got := agg.Result()
+			if got.TotalCount != len(tt.students) {
+				t.Errorf("TotalCount = %d, want %d", got.TotalCount, len(tt.students))
+			}
+			for branch, sum := range tt.sums {
+				if got.BranchSums[branch] != sum {
+					t.Errorf("BranchSums[%s] = %.2f, want %.2f", branch, got.BranchSums[branch], sum)
+				}
+			}
+			if got.TotalPasses != tt.passes {
+				t.Errorf("TotalPasses = %d, want %d", got.TotalPasses, tt.passes)
+			}
+			quiz := got.ComponentStats["quiz"]
+			if math.Abs(quiz.Mean-tt.quizMean) > 1e-9 || math.Abs(quiz.StdDev-tt.quizStdev) > 1e-9 {
+				t.Errorf("quiz stat = %+v, want mean %.4f stddev %.4f", quiz, tt.quizMean, tt.quizStdev)
+			}
+		})
+	}
+}
+
+func TestAggregatorEmpty(t *testing.T) {
+	stat := newAggregator().Result().ComponentStats["total"]
+	if !math.IsNaN(stat.Mean) || !math.IsNaN(stat.StdDev) {
+		t.Errorf("empty stat = %+v, want NaN", stat)
+	}
+}
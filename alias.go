@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// A flag that may be given several times, collecting every value
+type multiFlag []string
+
+func (m *multiFlag) String() string {
+	return strings.Join(*m, " ")
+}
+
+func (m *multiFlag) Set(value string) error {
+	*m = append(*m, value)
+	return nil
+}
+
+// Branch codes mapped to the alias they are aggregated under, from --branch-alias
+var branchAliases = make(map[string]string)
+
+// Member codes of each alias, in the order given
+var aliasMembers = make(map[string][]string)
+
+// Parses an alias definition such as CSE=2021A7,2022A7 into branchAliases
+func addBranchAlias(spec string) error {
+	alias, codes, ok := strings.Cut(spec, "=")
+	alias = strings.TrimSpace(alias)
+	if !ok || alias == "" || codes == "" {
+		return fmt.Errorf("expected ALIAS=CODE,CODE,... got %q", spec)
+	}
+	for _, code := range strings.Split(codes, ",") {
+		code = strings.TrimSpace(code)
+		if existing, taken := branchAliases[code]; taken && existing != alias {
+			return fmt.Errorf("branch %s is already aliased to %s", code, existing)
+		}
+		branchAliases[code] = alias
+		aliasMembers[alias] = append(aliasMembers[alias], code)
+	}
+	return nil
+}
+
+// Returns the key a branch is aggregated under: its alias if it has one, otherwise its code
+func branchGroup(code string) string {
+	if alias, exists := branchAliases[code]; exists {
+		return alias
+	}
+	return code
+}
+
+// Returns the display name of an aggregation key, listing the member codes for aliases
+func branchName(group string) string {
+	if members, exists := aliasMembers[group]; exists {
+		return strings.Join(members, ", ")
+	}
+	return branchMap[group]
+}
+
+// Splits a branch code such as 2024A7 into its admission year and program code. Reports
+// false when the code does not start with a four-digit year.
+func branchYear(code string) (year, program string, ok bool) {
+	if len(code) <= 4 {
+		return "", code, false
+	}
+	for _, r := range code[:4] {
+		if !unicode.IsDigit(r) {
+			return "", code, false
+		}
+	}
+	return code[:4], code[4:], true
+}
+
+// A program's statistics across every admission year, for --merge-years
+type mergedBranch struct {
+	Program string
+	Name    string
+	Codes   []string
+	Count   int
+	Average float64
+}
+
+// Aggregates students by program code, collapsing the admission years of their branch
+// codes. Each program is named after its members' branch names with the year removed.
+func mergeBranchYears(students []Student) []mergedBranch {
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	codes := make(map[string][]string)
+	for _, s := range students {
+		_, program, _ := branchYear(s.Branch)
+		sums[program] += s.Total
+		counts[program]++
+		if !slices.Contains(codes[program], s.Branch) {
+			codes[program] = append(codes[program], s.Branch)
+		}
+	}
+
+	var merged []mergedBranch
+	for _, program := range sortedBranches(counts) {
+		slices.Sort(codes[program])
+		var names []string
+		for _, code := range codes[program] {
+			year, _, _ := branchYear(code)
+			name := strings.Join(slices.DeleteFunc(strings.Fields(branchMap[code]), func(w string) bool { return w == year }), " ")
+			if name != "" && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+		merged = append(merged, mergedBranch{
+			Program: program,
+			Name:    strings.Join(names, " / "),
+			Codes:   codes[program],
+			Count:   counts[program],
+			Average: sums[program] / float64(counts[program]),
+		})
+	}
+	return merged
+}
+
+// Prints the branch averages with admission years merged, alongside the per-code view
+func printMergedYears(w io.Writer, students []Student) {
+	fmt.Fprintln(w, "\n"+bold("======================================"))
+	fmt.Fprintln(w, bold("Branch-Wise Averages Across Admission Years"))
+	for _, m := range mergeBranchYears(students) {
+		fmt.Fprintf(w, "Program %s (%s) Average Marks: %.2f (%d students from %s)\n",
+			m.Program, m.Name, m.Average, m.Count, strings.Join(m.Codes, ", "))
+	}
+}
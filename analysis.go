@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Groups students by branch code, or by alias for aliased branches
+func studentsByBranch(students []Student) map[string][]Student {
+	groups := make(map[string][]Student)
+	for _, s := range students {
+		groups[branchGroup(s.Branch)] = append(groups[branchGroup(s.Branch)], s)
+	}
+	return groups
+}
+
+// Returns the keys of a branch-keyed map in sorted order
+func sortedBranches[V any](m map[string]V) []string {
+	branches := make([]string, 0, len(m))
+	for branch := range m {
+		branches = append(branches, branch)
+	}
+	sort.Strings(branches)
+	return branches
+}
+
+// Returns the average value of a component over the given students
+func componentAverage(students []Student, comp component) float64 {
+	var sum float64
+	for _, s := range students {
+		sum += comp.getVal(s)
+	}
+	return sum / float64(len(students))
+}
+
+// Prints, for each branch, the component with the lowest average relative to its maximum
+func printWeakestComponents(w io.Writer, students []Student) {
+	fmt.Fprintln(w, "\n"+bold("======================================"))
+	fmt.Fprintln(w, bold("Weakest Component per Branch"))
+
+	groups := studentsByBranch(students)
+	for _, branch := range sortedBranches(groups) {
+		var weakest component
+		lowest := -1.0
+		for _, comp := range components {
+			if comp.key == "total" {
+				continue
+			}
+			pct := 100 * componentAverage(groups[branch], comp) / comp.max
+			if lowest < 0 || pct < lowest {
+				weakest, lowest = comp, pct
+			}
+		}
+		fmt.Fprintf(w, "%s's weakest component is %s at %.2f%% of max\n", branch, weakest.name(), lowest)
+	}
+}
+
+// Prints, for each branch, the top n students in every component
+func printGroupReport(w io.Writer, students []Student, n int) {
+	fmt.Fprintln(w, "\n"+bold("======================================"))
+	fmt.Fprintln(w, bold("Per-Branch Component Scorecard"))
+
+	groups := studentsByBranch(students)
+	for _, branch := range sortedBranches(groups) {
+		fmt.Fprintln(w, "\n"+bold(fmt.Sprintf("Branch %s (%s)", branch, branchName(branch))))
+		for _, comp := range reportComponents {
+			sorted := rankByComponent(groups[branch], comp)
+			var entries []string
+			for _, s := range sorted[:min(n, len(sorted))] {
+				entries = append(entries, fmt.Sprintf("%s (%.2f)", s.EmpID, comp.getVal(s)))
+			}
+			fmt.Fprintf(w, "  %s: %s\n", comp.name(), strings.Join(entries, ", "))
+		}
+	}
+}
+
+// A branch's position in the ranked branch comparison
+type BranchRank struct {
+	Code  string
+	Value float64
+	Count int
+	Rank  int // 0 when the branch is below the minimum size and excluded from ranking
+}
+
+// Ranks branches by mean or median total, leaving branches with fewer than minSize students unranked
+func rankBranches(students []Student, metric string, minSize int) []BranchRank {
+	var ranks []BranchRank
+	for branch, group := range studentsByBranch(students) {
+		totals := make([]float64, len(group))
+		for i, s := range group {
+			totals[i] = s.Total
+		}
+		value := mean(totals)
+		if metric == "median" {
+			value = median(totals)
+		}
+		ranks = append(ranks, BranchRank{Code: branch, Value: value, Count: len(group)})
+	}
+
+	sort.Slice(ranks, func(i, j int) bool {
+		if ranks[i].Value != ranks[j].Value {
+			return ranks[i].Value > ranks[j].Value
+		}
+		return ranks[i].Code < ranks[j].Code
+	})
+
+	rank := 0
+	for i := range ranks {
+		if ranks[i].Count >= minSize {
+			rank++
+			ranks[i].Rank = rank
+		}
+	}
+	return ranks
+}
+
+// Credits of each branch from --branch-credits, nil when not configured
+var branchCredits map[string]float64
+
+// Returns the mean of the branch averages weighted by each branch's credits, and the
+// branches left out because they have no credit value. The mean is NaN, reported as
+// "n/a", when no branch has credits.
+func creditWeightedAverage(results Results) (float64, []string) {
+	var weighted, credits float64
+	var missing []string
+	for _, branch := range sortedBranches(results.BranchSums) {
+		credit, ok := branchCredits[branch]
+		if !ok {
+			missing = append(missing, branch)
+			continue
+		}
+		weighted += credit * results.BranchSums[branch] / float64(results.BranchCounts[branch])
+		credits += credit
+	}
+	if credits == 0 {
+		return math.NaN(), missing
+	}
+	return weighted / credits, missing
+}
+
+// Returns the arithmetic mean of values
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// Prints branches ranked by the chosen metric, listing undersized branches as excluded
+func printBranchRanking(w io.Writer, students []Student, metric string, minSize int) {
+	fmt.Fprintln(w, "\n"+bold("======================================"))
+	fmt.Fprintln(w, bold(fmt.Sprintf("Branch Ranking (by %s total)", metric)))
+
+	for _, r := range rankBranches(students, metric, minSize) {
+		if r.Rank == 0 {
+			fmt.Fprintf(w, "-. %s (%s): %.2f (%d students) - excluded from ranking, fewer than %d students\n",
+				r.Code, branchName(r.Code), r.Value, r.Count, minSize)
+			continue
+		}
+		fmt.Fprintf(w, "%d. %s (%s): %.2f (%d students)\n", r.Rank, r.Code, branchName(r.Code), r.Value, r.Count)
+	}
+}
+
+// A branch's pull on the overall average
+type BranchContribution struct {
+	Code         string
+	Average      float64
+	Share        float64 // fraction of all accepted students
+	Contribution float64 // Share * (Average - overall average)
+}
+
+// Returns each branch's average minus the overall average, weighted by its share of the
+// students, ordered from the largest upward pull to the largest downward pull. The
+// contributions sum to zero, so together they account for where the overall average sits.
+func branchContributions(results Results) []BranchContribution {
+	overall := results.TotalSum / float64(results.TotalCount)
+	var contributions []BranchContribution
+	for branch, sum := range results.BranchSums {
+		count := results.BranchCounts[branch]
+		avg := sum / float64(count)
+		share := float64(count) / float64(results.TotalCount)
+		contributions = append(contributions, BranchContribution{Code: branch, Average: avg, Share: share, Contribution: share * (avg - overall)})
+	}
+	sort.Slice(contributions, func(i, j int) bool {
+		if contributions[i].Contribution != contributions[j].Contribution {
+			return contributions[i].Contribution > contributions[j].Contribution
+		}
+		return contributions[i].Code < contributions[j].Code
+	})
+	return contributions
+}
+
+// Prints each branch's contribution to the overall average
+func printBranchContributions(w io.Writer, results Results) {
+	fmt.Fprintln(w, "\n"+bold("======================================"))
+	fmt.Fprintln(w, bold("Branch Contribution to Overall Average"))
+	fmt.Fprintf(w, "Overall Average Marks: %.2f\n", results.TotalSum/float64(results.TotalCount))
+
+	for _, c := range branchContributions(results) {
+		fmt.Fprintf(w, "%s (%s): %+.2f (average %.2f, %.2f%% of students)\n",
+			c.Code, branchName(c.Code), c.Contribution, c.Average, 100*c.Share)
+	}
+}
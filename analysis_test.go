@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCreditWeightedAverage(t *testing.T) {
+	results := Results{
+		BranchSums:   map[string]float64{"2024A7": 500, "2024A3": 300, "2021A7": 200},
+		BranchCounts: map[string]int{"2024A7": 2, "2024A3": 1, "2021A7": 1},
+	}
+	tests := []struct {
+		name    string
+		credits map[string]float64
+		want    float64
+		missing []string
+	}{
+		{name: "every branch", credits: map[string]float64{"2024A7": 3, "2024A3": 1, "2021A7": 1}, want: (3*250 + 300 + 200) / 5.0},
+		{name: "some branches", credits: map[string]float64{"2024A7": 1, "2024A3": 3}, want: (250 + 3*300) / 4.0, missing: []string{"2021A7"}},
+		{name: "no matching branch", credits: map[string]float64{"2019A1": 4}, want: math.NaN(), missing: []string{"2021A7", "2024A3", "2024A7"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			branchCredits = tt.credits
+			t.Cleanup(func() { branchCredits = nil })
+			got, missing := creditWeightedAverage(results)
+			if got != tt.want && !(math.IsNaN(got) && math.IsNaN(tt.want)) {
+				t.Errorf("average = %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(missing, tt.missing) {
+				t.Errorf("missing = %v, want %v", missing, tt.missing)
+			}
+		})
+	}
+}
+
+func TestCreditWeightedAverageReportsNA(t *testing.T) {
+	results, err := processFixture(t, fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90))
+	if err != nil {
+		t.Fatal(err)
+	}
+	branchCredits = map[string]float64{"2019A1": 4}
+	t.Cleanup(func() { branchCredits = nil })
+
+	if avg := buildSummary(results).CreditWeightedAverage; avg != nil {
+		t.Errorf("CreditWeightedAverage = %v, want nil", *avg)
+	}
+	var buf bytes.Buffer
+	if err := printResults(&buf, results, columns); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "Credit-Weighted Overall Average Marks: n/a") {
+		t.Errorf("report lacks the n/a average:\n%s", buf.String())
+	}
+}
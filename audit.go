@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// Kinds of decisions recorded in the audit log
+const (
+	auditStart       = "start"
+	auditSkip        = "skip"
+	auditExclude     = "exclude"
+	auditDiscrepancy = "discrepancy"
+	auditNormalize   = "normalize"
+	auditComplete    = "complete"
+)
+
+// One decision made during processing, written as a line of JSON
+type AuditEvent struct {
+	Time   string `json:"time"`
+	Event  string `json:"event"`
+	Row    int    `json:"row,omitempty"`
+	EmpID  string `json:"empID,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// Destination of --audit-log events, nil when auditing is disabled
+var auditEncoder *json.Encoder
+
+// Opens the audit log, truncating any previous contents, and returns the function that
+// closes it and stops auditing
+func openAuditLog(path string) (func(), error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	auditEncoder = json.NewEncoder(f)
+	return func() {
+		auditEncoder = nil
+		f.Close()
+	}, nil
+}
+
+// Number of decisions recorded so far, counted even when no audit log is open so --dry-run
+// can report how large the log would be
+var auditEventCount int
+
+// First error writing the audit log, after which auditing stops
+var auditErr error
+
+// Records a decision in the audit log when one is open
+func audit(event string, row int, empID, reason string) {
+	auditEventCount++
+	slog.Debug(event, "row", row, "empid", empID, "reason", reason)
+	if auditEncoder == nil {
+		return
+	}
+	err := auditEncoder.Encode(AuditEvent{
+		Time:   time.Now().In(reportLocation).Format(time.RFC3339Nano),
+		Event:  event,
+		Row:    row,
+		EmpID:  empID,
+		Reason: reason,
+	})
+	if err != nil {
+		auditErr = fmt.Errorf("write audit log: %w", err)
+		auditEncoder = nil
+	}
+}
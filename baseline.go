@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Component averages from a prior run, keyed by component key
+type Baseline struct {
+	Overall  map[string]float64            `json:"overall"`
+	Branches map[string]map[string]float64 `json:"branches"`
+}
+
+// Computes the component averages of this run in baseline form
+func computeBaseline(students []Student) Baseline {
+	baseline := Baseline{
+		Overall:  componentAverages(students),
+		Branches: make(map[string]map[string]float64),
+	}
+	for branch, group := range studentsByBranch(students) {
+		baseline.Branches[branch] = componentAverages(group)
+	}
+	return baseline
+}
+
+// Returns the average of every component keyed by component key
+func componentAverages(students []Student) map[string]float64 {
+	averages := make(map[string]float64)
+	for _, comp := range components {
+		averages[comp.key] = round2(componentAverage(students, comp))
+	}
+	return averages
+}
+
+// Reads a baseline previously written with --save-baseline
+func loadBaseline(path string) (Baseline, error) {
+	var baseline Baseline
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return baseline, err
+	}
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return baseline, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return baseline, nil
+}
+
+// Writes this run's component averages to path for use as a future baseline
+func saveBaseline(path string, students []Student) error {
+	data, err := json.MarshalIndent(computeBaseline(students), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Prints this run's component averages minus the baseline, overall and per branch
+func printBaselineComparison(w io.Writer, students []Student, baseline Baseline) {
+	fmt.Fprintln(w, "\n"+bold("======================================"))
+	fmt.Fprintln(w, bold("Comparison with Baseline (this run minus baseline)"))
+
+	fmt.Fprintln(w, "Overall:")
+	printComponentDeltas(w, students, baseline.Overall)
+
+	groups := studentsByBranch(students)
+	for _, branch := range sortedBranches(groups) {
+		prior, exists := baseline.Branches[branch]
+		if !exists {
+			fmt.Fprintf(w, "Branch %s (%s): no baseline\n", branch, branchName(branch))
+			continue
+		}
+		fmt.Fprintf(w, "Branch %s (%s):\n", branch, branchName(branch))
+		printComponentDeltas(w, groups[branch], prior)
+	}
+}
+
+// Prints each component's current average and its delta against the prior averages
+func printComponentDeltas(w io.Writer, students []Student, prior map[string]float64) {
+	for _, comp := range components {
+		current := round2(componentAverage(students, comp))
+		before, exists := prior[comp.key]
+		if !exists {
+			fmt.Fprintf(w, "  %s: %.2f (no baseline)\n", comp.name(), current)
+			continue
+		}
+		fmt.Fprintf(w, "  %s: %.2f (baseline %.2f, %+.2f)\n", comp.name(), current, before, current-before)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// A file that could not be processed in a --keep-going run
+type batchFailure struct {
+	path string
+	err  error
+}
+
+// Processes each file in turn and prints its report, logging failures instead of exiting,
+// then prints which files succeeded and failed. Reports whether every file succeeded.
+func runBatch(w io.Writer, paths []string) bool {
+	base := saveRunState()
+	defer base.restore()
+	var succeeded []string
+	var failed []batchFailure
+	for _, path := range paths {
+		base.restore()
+		if err := runBatchFile(w, path); err != nil {
+			slog.Error("Failed to process file", "file", path, "error", err)
+			failed = append(failed, batchFailure{path, err})
+			continue
+		}
+		succeeded = append(succeeded, path)
+	}
+
+	fmt.Fprintln(w, "\n"+bold("======================================"))
+	fmt.Fprintln(w, bold("Batch Summary"))
+	fmt.Fprintf(w, "Succeeded: %d of %d files\n", len(succeeded), len(paths))
+	for _, path := range succeeded {
+		fmt.Fprintf(w, "OK: %s\n", path)
+	}
+	for _, f := range failed {
+		fmt.Fprintf(w, "FAILED: %s: %v\n", f.path, f.err)
+	}
+	return len(failed) == 0
+}
+
+// Processes one file of a batch through the same pipeline as a single-file run and prints
+// its report, which is left out entirely when the file fails partway
+func runBatchFile(w io.Writer, input string) error {
+	var report bytes.Buffer
+	err := runFile(input, true, func(print func(w io.Writer) error) error {
+		fmt.Fprintln(&report, "\n"+bold("File: "+input))
+		return print(&report)
+	})
+	if err != nil {
+		return err
+	}
+	_, err = report.WriteTo(w)
+	return err
+}
+
+// Returns the export path for one input of a batch: out with the input's base name added
+// before the extension, e.g. "dump.csv" becomes "dump-grades.csv" for grades.xlsx
+func batchArtifactPath(out, input string) string {
+	if isURL(input) {
+		input = path.Base(input)
+	}
+	name := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+	out = strings.TrimRight(out, `/\`)
+	ext := filepath.Ext(out)
+	return strings.TrimSuffix(out, ext) + "-" + name + ext
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunBatchResetsStatePerFile(t *testing.T) {
+	resetState(t)
+	first := writeTemp(t, "first.xlsx", buildBranchWorkbook(t, map[string]string{"2024A7": "Renamed CSE"},
+		fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90)))
+	second := writeFixture(t, fixtureRow("1002", "2024A7PS0002", 20, 55, 45, 20, 80))
+
+	var buf bytes.Buffer
+	if !runBatch(&buf, []string{first, second}) {
+		t.Fatalf("batch failed:\n%s", buf.String())
+	}
+	_, secondReport, _ := strings.Cut(buf.String(), "File: "+second)
+	if !strings.Contains(secondReport, "Branch 2024A7 (CSE 2024)") || strings.Contains(secondReport, "Renamed CSE") {
+		t.Errorf("second report uses the first file's branch names:\n%s", secondReport)
+	}
+	if branchMap["2024A7"] != "CSE 2024" {
+		t.Errorf("branch name after batch = %q, want it restored", branchMap["2024A7"])
+	}
+}
+
+func TestRunBatchKeepsGoingOnReportErrors(t *testing.T) {
+	resetState(t)
+	setFlag(t, compareFlag, "testdata/missing.xlsx")
+	paths := []string{writeFixture(t, fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90)), "testdata/sample.xlsx"}
+
+	var buf bytes.Buffer
+	if runBatch(&buf, paths) {
+		t.Fatal("batch succeeded, want both files to fail on the missing --compare file")
+	}
+	out := buf.String()
+	for _, path := range paths {
+		if !strings.Contains(out, "FAILED: "+path) {
+			t.Errorf("summary does not list %s as failed:\n%s", path, out)
+		}
+	}
+	if strings.Contains(out, "File: ") {
+		t.Errorf("batch printed a partial report:\n%s", out)
+	}
+}
+
+func TestRunBatchUsesTheSingleFilePipeline(t *testing.T) {
+	resetState(t)
+	dir := t.TempDir()
+	setFlag(t, formatFlag, "json")
+	setFlag(t, minCountFlag, 2)
+	setFlag(t, dumpOutFlag, filepath.Join(dir, "dump.csv"))
+	first := filepath.Join(dir, "first.xlsx")
+	second := filepath.Join(dir, "second.xlsx")
+	for _, path := range []string{first, second} {
+		if err := os.Rename(writeFixture(t,
+			fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90),
+			fixtureRow("1002", "2024A7PS0002", 20, 55, 45, 20, 80),
+			fixtureRow("1003", "2024A3PS0003", 28, 70, 55, 28, 100)), path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if !runBatch(&buf, []string{first, second}) {
+		t.Fatalf("batch failed:\n%s", buf.String())
+	}
+	if got := strings.Count(buf.String(), `"overallAverage":235`); got != 2 {
+		t.Errorf("found %d JSON summaries without the small 2024A3 branch, want 2:\n%s", got, buf.String())
+	}
+	for _, name := range []string{"dump-first.csv", "dump-second.csv"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lines := strings.Count(string(data), "\n"); lines != 3 {
+			t.Errorf("%s has %d lines, want a header and the two 2024A7 students", name, lines)
+		}
+	}
+}
+
+func TestBatchArtifactPath(t *testing.T) {
+	tests := []struct{ out, input, want string }{
+		{"dump.csv", "grades.xlsx", "dump-grades.csv"},
+		{"out/cards/", "2024/sem1.ods", "out/cards-sem1"},
+		{"summary.json", "https://example.com/files/grades.xlsx?x=1", "summary-grades.json"},
+	}
+	for _, tt := range tests {
+		if got := batchArtifactPath(tt.out, tt.input); got != tt.want {
+			t.Errorf("batchArtifactPath(%q, %q) = %q, want %q", tt.out, tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// One row of the branch comparison table
+type BranchSummaryRow struct {
+	Rank     string
+	Code     string
+	Name     string
+	Count    int
+	Average  float64
+	Median   float64
+	PassRate string // blank when pass rates were not requested
+}
+
+// Builds the ranked branch comparison table, ordered by --branch-metric
+func branchSummaryRows(students []Student, metric string, minSize int) []BranchSummaryRow {
+	groups := studentsByBranch(students)
+	var rows []BranchSummaryRow
+	for _, r := range rankBranches(students, metric, minSize) {
+		totals := totalsOf(groups[r.Code])
+		row := BranchSummaryRow{
+			Rank:    "-",
+			Code:    r.Code,
+			Name:    branchName(r.Code),
+			Count:   r.Count,
+			Average: mean(totals),
+			Median:  median(totals),
+		}
+		if r.Rank > 0 {
+			row.Rank = strconv.Itoa(r.Rank)
+		}
+		if passEnabled() {
+			passed := 0
+			for _, s := range groups[r.Code] {
+				if s.Total >= passThreshold(s.Branch) {
+					passed++
+				}
+			}
+			row.PassRate = fmt.Sprintf("%.2f%%", 100*float64(passed)/float64(r.Count))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// Writes the branch comparison table to path as CSV or HTML by extension
+func writeBranchSummary(path string, students []Student) error {
+	rows := branchSummaryRows(students, *branchMetric, *minBranchSize)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		err = writeBranchSummaryCSV(f, rows)
+	case ".html":
+		err = branchSummaryHTML.Execute(f, branchSummaryPage{Metric: *branchMetric, Rows: rows, Pass: passEnabled()})
+	default:
+		err = fmt.Errorf("unsupported branch summary extension %q: use .csv or .html", filepath.Ext(path))
+	}
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Prints the branch comparison table as aligned text
+func printBranchSummary(w io.Writer, rows []BranchSummaryRow) {
+	fmt.Fprintln(w, bold("======================================"))
+	fmt.Fprintln(w, bold(fmt.Sprintf("Branch Summary (ranked by %s total)", *branchMetric)))
+	fmt.Fprintf(w, "%-5s %-8s %-24s %8s %8s %8s", "Rank", "Branch", "Name", "Students", "Average", "Median")
+	if passEnabled() {
+		fmt.Fprintf(w, " %9s", "Pass Rate")
+	}
+	fmt.Fprintln(w)
+	for _, r := range rows {
+		fmt.Fprintf(w, "%-5s %-8s %-24s %8d %8.2f %8.2f", r.Rank, r.Code, r.Name, r.Count, r.Average, r.Median)
+		if passEnabled() {
+			fmt.Fprintf(w, " %9s", r.PassRate)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// Writes the branch comparison table as CSV
+func writeBranchSummaryCSV(w io.Writer, rows []BranchSummaryRow) error {
+	cw := csv.NewWriter(w)
+	header := []string{"rank", "branch", "branch_name", "students", "average", "median"}
+	if passEnabled() {
+		header = append(header, "pass_rate")
+	}
+	cw.Write(header)
+	for _, r := range rows {
+		record := []string{r.Rank, r.Code, r.Name, strconv.Itoa(r.Count), formatDumpValue(r.Average), formatDumpValue(r.Median)}
+		if passEnabled() {
+			record = append(record, r.PassRate)
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Data rendered by branchSummaryHTML
+type branchSummaryPage struct {
+	Metric string
+	Rows   []BranchSummaryRow
+	Pass   bool
+}
+
+// One-page HTML rendering of the branch comparison table
+var branchSummaryHTML = htmltemplate.Must(htmltemplate.New("branches").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Branch Summary</title></head>
+<body>
+<h1>Branch Summary</h1>
+<p>Ranked by {{.Metric}} total</p>
+<table border="1" cellpadding="4">
+<tr><th>Rank</th><th>Branch</th><th>Name</th><th>Students</th><th>Average</th><th>Median</th>{{if .Pass}}<th>Pass Rate</th>{{end}}</tr>
+{{range .Rows}}<tr><td>{{.Rank}}</td><td>{{.Code}}</td><td>{{.Name}}</td><td>{{.Count}}</td><td>{{printf "%.2f" .Average}}</td><td>{{printf "%.2f" .Median}}</td>{{if $.Pass}}<td>{{.PassRate}}</td>{{end}}</tr>
+{{end}}</table>
+</body></html>
+`))
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// A rectangular block of cells, with 1-based inclusive coordinates
+type cellRange struct {
+	fromCol, fromRow int
+	toCol, toRow     int
+}
+
+// Data region parsed from --range, nil when the whole sheet is used
+var dataRange *cellRange
+
+// Parses a range such as A1:K200
+func parseCellRange(spec string) (*cellRange, error) {
+	from, to, ok := strings.Cut(spec, ":")
+	if !ok {
+		return nil, fmt.Errorf("expected a range like A1:K200")
+	}
+	fromCol, fromRow, err := excelize.CellNameToCoordinates(from)
+	if err != nil {
+		return nil, err
+	}
+	toCol, toRow, err := excelize.CellNameToCoordinates(to)
+	if err != nil {
+		return nil, err
+	}
+	if toCol < fromCol || toRow < fromRow {
+		return nil, fmt.Errorf("range %s ends before it starts", spec)
+	}
+
+	return &cellRange{fromCol: fromCol, fromRow: fromRow, toCol: toCol, toRow: toRow}, nil
+}
+
+// Checks that the range is wide enough for every column the layout reads
+func (r *cellRange) checkWidth(layout ColumnSpec) error {
+	needed := max(layout.width(), *minValidColsFlag)
+	if width := r.toCol - r.fromCol + 1; width < needed {
+		from, _ := excelize.CoordinatesToCellName(r.fromCol, r.fromRow)
+		to, _ := excelize.CoordinatesToCellName(r.toCol, r.toRow)
+		return fmt.Errorf("range %s:%s spans %d columns, the column layout needs %d", from, to, width, needed)
+	}
+	return nil
+}
+
+// Returns the 1-based sheet row and column numbers of the range's top-left cell
+func rangeOrigin() (col, row int) {
+	if dataRange == nil {
+		return 1, 1
+	}
+	return dataRange.fromCol, dataRange.fromRow
+}
+
+// Reports whether the 0-based sheet row index falls inside --range
+func rowInRange(r int) bool {
+	return dataRange == nil || (r >= dataRange.fromRow-1 && r < dataRange.toRow)
+}
+
+// Restricts a row's cells to the columns inside --range
+func clipRow(row []string) []string {
+	if dataRange == nil {
+		return row
+	}
+	start := min(dataRange.fromCol-1, len(row))
+	end := min(dataRange.toCol, len(row))
+	return row[start:end]
+}
+
+// Restricts rows read from a sheet to the cells inside --range
+func clipToRange(rows [][]string) [][]string {
+	if dataRange == nil {
+		return rows
+	}
+
+	var clipped [][]string
+	for i := dataRange.fromRow - 1; i < dataRange.toRow && i < len(rows); i++ {
+		clipped = append(clipped, clipRow(rows[i]))
+	}
+	return clipped
+}
@@ -0,0 +1,57 @@
+package main
+
+import "testing"
+
+func TestParseCellRange(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    cellRange
+		wantErr bool
+	}{
+		{spec: "A1:K200", want: cellRange{fromCol: 1, fromRow: 1, toCol: 11, toRow: 200}},
+		{spec: "B3:Z10", want: cellRange{fromCol: 2, fromRow: 3, toCol: 26, toRow: 10}},
+		{spec: "A1", wantErr: true},
+		{spec: "K200:A1", wantErr: true},
+		{spec: "1A:K2", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := parseCellRange(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && *got != tt.want {
+				t.Errorf("parseCellRange = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCellRangeCheckWidth(t *testing.T) {
+	narrow := ColumnSpec{EmpID: 0, CampusID: 1, Quiz: 2, MidSem: 3, LabTest: 4, WeeklyLabs: 5, Compre: 6, Total: 7}
+	withQuizzes := defaultColumnSpec
+	withQuizzes.QuizColumns = []int{11, 12, 13}
+	tests := []struct {
+		name    string
+		spec    string
+		layout  ColumnSpec
+		wantErr bool
+	}{
+		{name: "default layout fits", spec: "A1:K200", layout: defaultColumnSpec},
+		{name: "default layout too narrow", spec: "A1:H200", layout: defaultColumnSpec, wantErr: true},
+		{name: "detected layout fits", spec: "A1:H200", layout: narrow},
+		{name: "quiz columns outside", spec: "A1:K200", layout: withQuizzes, wantErr: true},
+		{name: "quiz columns inside", spec: "A1:N200", layout: withQuizzes},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := parseCellRange(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.checkWidth(tt.layout); (err != nil) != tt.wantErr {
+				t.Errorf("checkWidth = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Fill colors of the grade segments, assigned by grade order so a grade keeps its color
+// across branches and runs; the last color is reserved for students below every cutoff
+var gradeColors = []string{"#1a9850", "#66bd63", "#a6d96a", "#d9ef8b", "#fee08b", "#fdae61", "#f46d43", "#d73027"}
+
+// Geometry of the grade distribution chart, in pixels
+const (
+	chartLabelWidth = 220
+	chartBarWidth   = 520
+	chartBarHeight  = 20
+	chartBarGap     = 8
+	chartLegendRow  = 30
+)
+
+// Returns the color of the grade at position i of grades, whose last entry is the
+// below-cutoffs grade
+func gradeColor(i, grades int) string {
+	if i == grades-1 {
+		return gradeColors[len(gradeColors)-1]
+	}
+	return gradeColors[i%(len(gradeColors)-1)]
+}
+
+// Renders one stacked bar per branch, ordered by average total, with a segment per grade
+// sized by the share of the branch's students holding it
+func gradeDistributionSVG(students []Student, cutoffs []gradeCutoff) string {
+	grades := make([]string, 0, len(cutoffs)+1)
+	for _, c := range cutoffs {
+		grades = append(grades, c.Grade)
+	}
+	grades = append(grades, belowCutoffsGrade)
+
+	groups := studentsByBranch(students)
+	branches := sortedBranches(groups)
+	sort.SliceStable(branches, func(i, j int) bool {
+		return mean(totalsOf(groups[branches[i]])) > mean(totalsOf(groups[branches[j]]))
+	})
+
+	width := chartLabelWidth + chartBarWidth + 60
+	height := chartLegendRow + len(branches)*(chartBarHeight+chartBarGap) + chartBarGap
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`+"\n", width, height)
+
+	x := chartLabelWidth
+	for i, grade := range grades {
+		fmt.Fprintf(&b, `<rect x="%d" y="8" width="12" height="12" fill="%s"/><text x="%d" y="18">%s</text>`+"\n",
+			x, gradeColor(i, len(grades)), x+16, html.EscapeString(grade))
+		x += 24 + 7*len(grade)
+	}
+
+	for row, branch := range branches {
+		group := groups[branch]
+		counts := make(map[string]int)
+		for _, s := range group {
+			counts[assignGrade(s.Total, cutoffs)]++
+		}
+
+		y := chartLegendRow + row*(chartBarHeight+chartBarGap)
+		label := fmt.Sprintf("%s (%s)", branch, branchName(branch))
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n", chartLabelWidth-8, y+chartBarHeight-6, html.EscapeString(label))
+		offset := 0.0
+		for i, grade := range grades {
+			if counts[grade] == 0 {
+				continue
+			}
+			share := float64(counts[grade]) / float64(len(group))
+			fmt.Fprintf(&b, `<rect x="%.2f" y="%d" width="%.2f" height="%d" fill="%s"><title>%s: %d of %d (%.2f%%)</title></rect>`+"\n",
+				float64(chartLabelWidth)+offset, y, share*chartBarWidth, chartBarHeight, gradeColor(i, len(grades)),
+				html.EscapeString(grade), counts[grade], len(group), 100*share)
+			offset += share * chartBarWidth
+		}
+		fmt.Fprintf(&b, `<text x="%d" y="%d">%d</text>`+"\n", chartLabelWidth+chartBarWidth+6, y+chartBarHeight-6, len(group))
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// Writes the grade distribution chart to path as a standalone .svg, or as an .html page
+// embedding it
+func writeGradeChart(path string, students []Student) error {
+	svg := gradeDistributionSVG(students, gradeCutoffs)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".svg":
+	case ".html":
+		svg = "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>Grade Distribution</title></head>\n<body>\n<h1>Grade Distribution by Branch</h1>\n" +
+			svg + "</body></html>\n"
+	default:
+		return fmt.Errorf("unsupported chart extension %q: use .svg or .html", filepath.Ext(path))
+	}
+	return os.WriteFile(path, []byte(svg), 0o644)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Fraction of a column's values above its maximum at which the column is considered misplaced
+const swappedColumnFraction = 0.5
+
+// Warns when most values in a component column exceed that component's maximum,
+// which usually means the columns were swapped or mislabeled
+func checkColumnRanges(students []Student) {
+	if len(students) == 0 {
+		return
+	}
+	for _, comp := range components {
+		over := 0
+		for _, s := range students {
+			if comp.getVal(s) > comp.max+*toleranceFlag {
+				over++
+			}
+		}
+		if float64(over)/float64(len(students)) > swappedColumnFraction {
+			recordFinding(levelWarning, "%d of %d %s values exceed the maximum of %g; the column may be swapped or mislabeled",
+				over, len(students), comp.name(), comp.max)
+		}
+	}
+}
+
+// Reports every component value above its maximum as possibly curved. These are errors
+// unless allowCurve is set, in which case they are recorded as informational notices.
+func checkCurvedValues(students []Student, allowCurve bool) {
+	level := levelError
+	if allowCurve {
+		level = levelInfo
+	}
+	for _, s := range students {
+		for _, comp := range components {
+			if v := comp.getVal(s); v > comp.max+*toleranceFlag {
+				recordFinding(level, "Possibly curved: %s %s has %s %.2f, above the cap of %g",
+					idLabel, s.EmpID, comp.name(), v, comp.max)
+			}
+		}
+	}
+}
+
+// Flags students whose Total is at least minTotal while one of the major components is
+// zero or blank. The Total column then usually masks missing data, so these need review.
+func checkInconsistentScores(students []Student, minTotal float64, major []component) {
+	for _, s := range students {
+		if s.Total < minTotal {
+			continue
+		}
+		for _, comp := range major {
+			if comp.getVal(s) == 0 {
+				audit(auditDiscrepancy, s.Row, s.EmpID, fmt.Sprintf("total %.2f with no %s marks", s.Total, comp.label))
+				recordFinding(levelWarning, "Inconsistent scores for %s %s: Total %.2f but %s is zero or blank; needs review",
+					idLabel, s.EmpID, s.Total, comp.name())
+			}
+		}
+	}
+}
+
+// Flags rows whose Total departs from the component sum by far more than the cohort's typical
+// offset. A uniform curve shifts every row by the same amount, so only rows that stray from the
+// median offset by more than threshold are reported, as likely formula or paste errors.
+func checkGrossDiscrepancies(students []Student, threshold float64) {
+	if len(students) == 0 {
+		return
+	}
+
+	deltas := make([]float64, len(students))
+	for i, s := range students {
+		deltas[i] = s.Total - computedTotal(s)
+	}
+	offset := median(deltas)
+
+	for i, s := range students {
+		if math.Abs(deltas[i]-offset) > threshold {
+			audit(auditDiscrepancy, s.Row, s.EmpID, fmt.Sprintf("likely formula or paste error, off by %+.2f against cohort offset %+.2f", deltas[i], offset))
+			recordFinding(levelError, "Likely formula or paste error for %s %s: Total %.2f vs component sum %.2f (off by %+.2f, cohort offset %+.2f)",
+				idLabel, s.EmpID, s.Total, computedTotal(s), deltas[i], offset)
+		}
+	}
+}
+
+// Returns the median of values without modifying the slice
+func median(values []float64) float64 {
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// Largest delta treated as rounding, and as a small discrepancy, when bucketing discrepancies
+const (
+	roundingDelta = 1.0
+	smallDelta    = 5.0
+)
+
+// Sample EmpIDs listed per discrepancy bucket
+const bucketSamples = 5
+
+// Prints total discrepancies grouped by the likely cause suggested by their delta: rounding,
+// a small slip, a component left out of or counted twice in the Total, or a large unexplained gap
+func printDiscrepancyBuckets(w io.Writer, students []Student) {
+	fmt.Fprintln(w, "\n"+bold("======================================"))
+	fmt.Fprintln(w, bold("Total Discrepancies by Delta"))
+
+	var order []string
+	buckets := make(map[string][]string)
+	for _, s := range students {
+		delta := s.Total - computedTotal(s)
+		if isWithinTolerance(computedTotal(s), s.Total) {
+			continue
+		}
+		bucket := discrepancyBucket(s, delta)
+		if _, ok := buckets[bucket]; !ok {
+			order = append(order, bucket)
+		}
+		buckets[bucket] = append(buckets[bucket], s.EmpID)
+	}
+
+	if len(order) == 0 {
+		fmt.Fprintln(w, "No discrepancies")
+		return
+	}
+	sort.SliceStable(order, func(i, j int) bool { return len(buckets[order[i]]) > len(buckets[order[j]]) })
+	for _, bucket := range order {
+		ids := buckets[bucket]
+		sample := strings.Join(ids[:min(bucketSamples, len(ids))], ", ")
+		if len(ids) > bucketSamples {
+			sample += ", ..."
+		}
+		fmt.Fprintf(w, "%s: %d student(s) (%s)\n", bucket, len(ids), sample)
+	}
+}
+
+// Names the likely cause of a discrepancy of delta = Total - component sum
+func discrepancyBucket(s Student, delta float64) string {
+	if math.Abs(delta) <= roundingDelta {
+		return fmt.Sprintf("Rounding (within %g)", roundingDelta)
+	}
+	for _, comp := range components {
+		if comp.key == "total" || comp.getVal(s) == 0 {
+			continue
+		}
+		switch {
+		case math.Abs(delta+comp.getVal(s)) <= roundingDelta:
+			return fmt.Sprintf("Off by %s's worth (missing from Total)", comp.label)
+		case math.Abs(delta-comp.getVal(s)) <= roundingDelta:
+			return fmt.Sprintf("Off by %s's worth (counted twice in Total)", comp.label)
+		}
+	}
+	if math.Abs(delta) <= smallDelta {
+		return fmt.Sprintf("Small (within %g)", smallDelta)
+	}
+	if delta > 0 {
+		return fmt.Sprintf("Large, Total above component sum (over %g)", smallDelta)
+	}
+	return fmt.Sprintf("Large, Total below component sum (over %g)", smallDelta)
+}
+
+// Returns an error when more than limit students have a Total that disagrees with the
+// component sum beyond the tolerance. A negative limit disables the check.
+func checkDiscrepancyLimit(students []Student, limit int) error {
+	if limit < 0 {
+		return nil
+	}
+	if count := len(discrepantStudents(students)); count > limit {
+		return fmt.Errorf("%d rows have total discrepancies, exceeding --fail-on-discrepancy %d", count, limit)
+	}
+	return nil
+}
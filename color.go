@@ -0,0 +1,58 @@
+package main
+
+import "os"
+
+const (
+	ansiReset = "\033[0m"
+	ansiBold  = "\033[1m"
+	ansiRed   = "\033[31m"
+	ansiGreen = "\033[1;32m"
+)
+
+// Whether ANSI colors are written to stdout and stderr, decided once at startup
+var (
+	stdoutColor bool
+	stderrColor bool
+)
+
+// Enables colors for each stream that is a terminal unless disabled by flag or NO_COLOR;
+// the report goes uncolored when written to a file
+func setupColor(disabled, reportToFile bool) {
+	if disabled || os.Getenv("NO_COLOR") != "" {
+		return
+	}
+	stdoutColor = isTerminal(os.Stdout) && !reportToFile
+	stderrColor = isTerminal(os.Stderr)
+}
+
+// Reports whether f is attached to a character device such as a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Wraps s in the given ANSI code when enabled
+func colorize(enabled bool, code, s string) string {
+	if !enabled {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// Formats a report header for stdout
+func bold(s string) string {
+	return colorize(stdoutColor, ansiBold, s)
+}
+
+// Formats the top entry of a list for stdout
+func highlight(s string) string {
+	return colorize(stdoutColor, ansiGreen, s)
+}
+
+// Formats a discrepancy warning for stderr
+func alert(s string) string {
+	return colorize(stderrColor, ansiRed, s)
+}
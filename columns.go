@@ -0,0 +1,318 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Zero-based column index of each field in a data row
+type ColumnSpec struct {
+	EmpID      int
+	CampusID   int
+	Quiz       int
+	MidSem     int
+	LabTest    int
+	WeeklyLabs int
+	Compre     int
+	Total      int
+
+	// Individual quiz columns aggregated into Quiz by --quiz-best-of; empty to read Quiz directly
+	QuizColumns []int
+
+	// Compre attempt columns in sitting order, chosen between by --compre-rule; empty to read Compre directly
+	CompreColumns []int
+
+	// Individual weekly lab columns summed into WeeklyLabs; empty to read WeeklyLabs directly
+	WeeklyColumns []int
+}
+
+// Layout of the standard grade sheet export
+var defaultColumnSpec = ColumnSpec{
+	EmpID:      2,
+	CampusID:   3,
+	Quiz:       4,
+	MidSem:     5,
+	LabTest:    6,
+	WeeklyLabs: 7,
+	Compre:     9,
+	Total:      10,
+}
+
+// Column layout used for parsing
+var columns = defaultColumnSpec
+
+// Returns the indices of every field in the spec
+func (c ColumnSpec) indices() []int {
+	return []int{c.EmpID, c.CampusID, c.Quiz, c.MidSem, c.LabTest, c.WeeklyLabs, c.Compre, c.Total}
+}
+
+// Returns a copy of the spec with every column moved right by n
+func (c ColumnSpec) shifted(n int) ColumnSpec {
+	shift := func(indices []int) []int {
+		var moved []int
+		for _, idx := range indices {
+			moved = append(moved, idx+n)
+		}
+		return moved
+	}
+	c.EmpID += n
+	c.CampusID += n
+	c.Quiz += n
+	c.MidSem += n
+	c.LabTest += n
+	c.WeeklyLabs += n
+	c.Compre += n
+	c.Total += n
+	c.QuizColumns = shift(c.QuizColumns)
+	c.CompreColumns = shift(c.CompreColumns)
+	c.WeeklyColumns = shift(c.WeeklyColumns)
+	return c
+}
+
+// Returns the number of cells a row needs to cover every column in the spec
+func (c ColumnSpec) MinColumns() int {
+	required := 0
+	for _, idx := range c.indices() {
+		required = max(required, idx+1)
+	}
+	return required
+}
+
+// Returns the number of columns up to the last one the spec reads, counting the
+// individual quiz, compre and weekly columns
+func (c ColumnSpec) width() int {
+	width := c.MinColumns()
+	for _, idx := range slices.Concat(c.QuizColumns, c.CompreColumns, c.WeeklyColumns) {
+		width = max(width, idx+1)
+	}
+	return width
+}
+
+// Returns the minimum row length for a row to be parsed, honoring --min-valid-columns
+func minColumns() int {
+	return max(columns.MinColumns(), *minValidColsFlag)
+}
+
+// Returns the layout with every field placed at the header cell carrying one of its
+// expected labels, the first one when several do. Fails naming the fields whose label
+// appears nowhere.
+func detectColumns(header []string, base ColumnSpec) (ColumnSpec, error) {
+	keys := headerKeys(header)
+	spec := base
+	var missing []string
+	for i, spellings := range expectedHeaderLabels() {
+		idx := findHeader(keys, spellings)
+		if idx < 0 {
+			missing = append(missing, headerFields[i].name)
+			continue
+		}
+		*headerFields[i].field(&spec) = idx
+	}
+	if len(missing) > 0 {
+		return base, fmt.Errorf("header has no %s column", strings.Join(missing, ", "))
+	}
+	return spec, nil
+}
+
+// Settles the data sheet and column layout for filePath, starting from base: picks the
+// sheet under --sheet-auto, places the columns by header under --detect-columns or shifts
+// them by the column offset, then checks the final layout against --range,
+// --validate-schema and --strict-columns
+func prepareLayout(filePath string, base ColumnSpec) error {
+	columns = base
+	if *sheetAutoFlag {
+		if err := selectDataSheet(filePath); err != nil {
+			return err
+		}
+	}
+
+	if *detectColumnsFlag {
+		detected, err := columnsFromHeader(filePath, base)
+		if err != nil {
+			return fmt.Errorf("detect columns: %w", err)
+		}
+		columns = detected
+	} else {
+		offset := *colOffsetFlag
+		if offset < 0 {
+			offset = detectColumnOffset(filePath)
+			if offset > 0 {
+				recordFinding(levelWarning, "Branches only parse with every column shifted right by %d (likely a leading index column); using the shifted layout. Pass --col-offset 0 to disable.", offset)
+			}
+		}
+		columns = base.shifted(offset)
+	}
+
+	if dataRange != nil {
+		if err := dataRange.checkWidth(columns); err != nil {
+			return fmt.Errorf("invalid --range: %w", err)
+		}
+	}
+
+	if *schemaFlag != "" {
+		if err := validateSchema(filePath, *schemaFlag); err != nil {
+			return fmt.Errorf("schema validation failed: %w", err)
+		}
+	}
+	if *strictColumnsFlag {
+		if err := checkExtraColumns(filePath); err != nil {
+			return fmt.Errorf("strict column check failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// Reads the header row of filePath and places the layout's fields by their labels
+func columnsFromHeader(filePath string, base ColumnSpec) (ColumnSpec, error) {
+	header, err := readHeader(filePath)
+	if err != nil {
+		return base, err
+	}
+	spec, err := detectColumns(header, base)
+	if err == nil && *idColumnFlag >= 0 {
+		spec.EmpID = *idColumnFlag
+	}
+	return spec, err
+}
+
+// Parses comma-separated column letters such as L,M,N into zero-based indices
+func parseColumnLetters(spec string) ([]int, error) {
+	var indices []int
+	for _, name := range strings.Split(spec, ",") {
+		num, err := excelize.ColumnNameToNumber(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		indices = append(indices, num-1)
+	}
+	return indices, nil
+}
+
+// Reads each weekly lab score, treating blank or missing cells as zero
+func weeklyScores(row []string, indices []int) []float64 {
+	weeks := make([]float64, len(indices))
+	for i, idx := range indices {
+		if idx < len(row) {
+			weeks[i], _ = parseNumber(row[idx])
+		}
+	}
+	return weeks
+}
+
+// Aggregates the best k non-blank quiz scores in row, or all of them when fewer than k are
+// present. A cell that does not parse is left out on its own, like a blank one; malformedCells
+// reports it.
+func bestOfQuizzes(row []string, indices []int, k int, aggregate string) float64 {
+	var scores []float64
+	for _, idx := range indices {
+		if idx >= len(row) || row[idx] == "" {
+			continue
+		}
+		score, err := parseNumber(row[idx])
+		if err != nil {
+			continue
+		}
+		scores = append(scores, score)
+	}
+	if len(scores) == 0 {
+		return 0
+	}
+
+	sort.Sort(sort.Reverse(sort.Float64Slice(scores)))
+	best := scores[:min(k, len(scores))]
+	var sum float64
+	for _, score := range best {
+		sum += score
+	}
+	if aggregate == "average" {
+		return sum / float64(len(best))
+	}
+	return sum
+}
+
+// Chooses between compre attempts: the highest score for "max", the last non-blank for "latest".
+// Returns the chosen score and its 1-based attempt number, or 0 when every attempt is blank.
+func selectCompre(row []string, indices []int, rule string) (float64, int, error) {
+	var chosen float64
+	attempt := 0
+	for i, idx := range indices {
+		if idx >= len(row) || row[idx] == "" {
+			continue
+		}
+		score, err := parseNumber(row[idx])
+		if err != nil {
+			return 0, 0, fmt.Errorf("compre column %d: %w", idx+1, err)
+		}
+		if attempt == 0 || rule == "latest" || score > chosen {
+			chosen, attempt = score, i+1
+		}
+	}
+	return chosen, attempt, nil
+}
+
+// Data rows sampled when detecting a leading index column
+const offsetSampleRows = 100
+
+// Minimum fraction of sampled rows with a valid branch required to adopt a shifted layout,
+// and the minimum improvement over the default layout, keeping detection conservative
+const (
+	offsetMinSuccess = 0.8
+	offsetMinGain    = 0.5
+)
+
+// Returns 1 when the leading data rows have mostly invalid branches under the configured
+// layout but valid ones with every column shifted right by one, and 0 otherwise
+func detectColumnOffset(filePath string) int {
+	sampled, plain, shifted := 0, 0, 0
+	err := scanRows(filePath, func(i int, row []string) bool {
+		if isHeaderRow(i) || len(row) == 0 || isSummaryRow(row) {
+			return true
+		}
+		sampled++
+		if validBranchAt(row, columns.CampusID) {
+			plain++
+		}
+		if validBranchAt(row, columns.CampusID+1) {
+			shifted++
+		}
+		return sampled < offsetSampleRows
+	})
+	if err != nil || sampled == 0 {
+		return 0
+	}
+
+	plainRate := float64(plain) / float64(sampled)
+	shiftedRate := float64(shifted) / float64(sampled)
+	if shiftedRate >= offsetMinSuccess && shiftedRate-plainRate >= offsetMinGain {
+		return 1
+	}
+	return 0
+}
+
+// Reports whether the cell at idx holds a campus ID with a known branch
+func validBranchAt(row []string, idx int) bool {
+	return idx < len(row) && extractBranch(cleanCell(row[idx])) != ""
+}
+
+// Returns an error listing the header cells beyond the last column the layout reads,
+// including the individual quiz, compre and weekly columns
+func checkExtraColumns(filePath string) error {
+	header, err := readHeader(filePath)
+	if err != nil {
+		return err
+	}
+	expected := columns.width()
+	if len(header) <= expected {
+		return nil
+	}
+	var extra []string
+	for i := expected; i < len(header); i++ {
+		extra = append(extra, fmt.Sprintf("%s %q", columnName(i), header[i]))
+	}
+	return fmt.Errorf("sheet has %d columns but the column layout reads %d; unexpected columns: %s",
+		len(header), expected, strings.Join(extra, ", "))
+}
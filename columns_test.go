@@ -0,0 +1,117 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDetectColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  []string
+		want    ColumnSpec
+		wantErr string
+	}{
+		{
+			name:   "standard layout",
+			header: []string{"Sl", "Class No", "Emplid", "Campus ID", "Quiz (30)", "Mid-Sem (75)", "Lab Test (60)", "Weekly Labs (30)", "Pre-Compre (195)", "Compre (105)", "Total (300)"},
+			want:   defaultColumnSpec,
+		},
+		{
+			name:   "reordered with extra column",
+			header: []string{"Notes", "Total", " campus id ", "EMPID", "compre", "weekly labs", "lab test", "MidSem", "quiz"},
+			want:   ColumnSpec{EmpID: 3, CampusID: 2, Quiz: 8, MidSem: 7, LabTest: 6, WeeklyLabs: 5, Compre: 4, Total: 1},
+		},
+		{
+			name:    "missing columns",
+			header:  []string{"Emplid", "Campus ID", "Quiz", "Mid-Sem", "Weekly Labs", "Compre"},
+			wantErr: "Lab Test, Total",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := detectColumns(tt.header, defaultColumnSpec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one naming %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.MinColumns() != tt.want.MinColumns() || got.EmpID != tt.want.EmpID || got.CampusID != tt.want.CampusID ||
+				got.Quiz != tt.want.Quiz || got.MidSem != tt.want.MidSem || got.LabTest != tt.want.LabTest ||
+				got.WeeklyLabs != tt.want.WeeklyLabs || got.Compre != tt.want.Compre || got.Total != tt.want.Total {
+				t.Errorf("detectColumns = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrepareLayoutDetectsBeforeValidating(t *testing.T) {
+	resetState(t)
+	setFlag(t, detectColumnsFlag, true)
+	header := []any{"Emplid", "Campus ID", "Total", "Quiz", "Mid-Sem", "Lab Test", "Weekly Labs", "Compre", "Notes"}
+	path := writeWorkbook(t, header, []any{"1001", "2024A7PS0001", 250, 25, 60, 50, 25, 90, "late"})
+
+	if err := prepareLayout(path, defaultColumnSpec); err != nil {
+		t.Fatal(err)
+	}
+	results, err := processFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Students) != 1 || results.Students[0].Total != 250 || results.Students[0].Compre != 90 {
+		t.Errorf("students = %+v, want 1001 with Total 250 and Compre 90", results.Students)
+	}
+
+	// The Notes column lies beyond the detected layout, though not beyond the default one
+	setFlag(t, strictColumnsFlag, true)
+	err = prepareLayout(path, defaultColumnSpec)
+	if err == nil || !strings.Contains(err.Error(), `"Notes"`) {
+		t.Errorf("strict check err = %v, want one naming the Notes column", err)
+	}
+}
+
+func TestStrictColumnsAllowsExtraColumnFlags(t *testing.T) {
+	header := slices.Concat(fixtureHeader, []any{"Quiz 1", "Quiz 2", "Compre Makeup", "Week 1", "Week 2"})
+	row := slices.Concat(fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90), []any{12, 13, 0, 12, 13})
+	path := writeWorkbook(t, header, row)
+	spec := defaultColumnSpec
+	spec.QuizColumns = []int{11, 12}
+	spec.CompreColumns = []int{9, 13}
+	spec.WeeklyColumns = []int{14, 15}
+
+	resetState(t)
+	setFlag(t, strictColumnsFlag, true)
+	if err := prepareLayout(path, spec); err != nil {
+		t.Errorf("configured extra columns rejected: %v", err)
+	}
+
+	spec.WeeklyColumns = []int{14}
+	err := prepareLayout(path, spec)
+	if err == nil || !strings.Contains(err.Error(), `P "Week 2"`) || strings.Contains(err.Error(), "Quiz 1") {
+		t.Errorf("err = %v, want only the unread Week 2 column reported", err)
+	}
+}
+
+func TestBestOfQuizzesSkipsOnlyTheMalformedCell(t *testing.T) {
+	header := slices.Concat(fixtureHeader, []any{"Quiz 1", "Quiz 2", "Quiz 3"})
+	row := slices.Concat(fixtureRow("1001", "2024A7PS0001", 18, 60, 50, 25, 90), []any{10, "NA", 8})
+	resetState(t)
+	columns.QuizColumns = []int{11, 12, 13}
+	setFlag(t, quizBestOfFlag, 2)
+
+	results, err := processReader(buildWorkbook(t, header, row))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Students) != 1 || results.Students[0].Quiz != 18 {
+		t.Fatalf("students = %+v, want quiz 18 from the two parseable quizzes", results.Students)
+	}
+	if errs := findingMessages(levelError); len(errs) != 1 || !strings.Contains(errs[0], `"NA"`) {
+		t.Errorf("error findings = %q, want the NA quiz cell reported", errs)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Names of the table of contents and aggregate sheets in a --combined-report workbook
+const (
+	contentsSheet  = "Contents"
+	aggregateSheet = "All Sheets"
+)
+
+// Longest sheet name Excel accepts
+const maxSheetNameLength = 31
+
+// Results of processing one sheet of the input workbook
+type sheetResults struct {
+	name    string
+	results Results
+}
+
+// Returns the names of the input workbook's sheets holding grade data, skipping the Branches sheet
+func dataSheetNames(filePath string) ([]string, error) {
+	var names []string
+	if isODS(filePath) {
+		sheets, err := readODS(filePath)
+		if err != nil {
+			return nil, err
+		}
+		for _, sheet := range sheets {
+			names = append(names, sheet.name)
+		}
+	} else {
+		f, err := openWorkbook(filePath)
+		if err != nil {
+			return nil, err
+		}
+		names = f.GetSheetList()
+		f.Close()
+	}
+	return slices.DeleteFunc(names, func(name string) bool { return name == branchSheetName }), nil
+}
+
+// Processes every data sheet of the input on its own, with its layout prepared from base,
+// and writes a workbook with a statistics sheet per input sheet, an aggregate sheet and a
+// table of contents
+func writeCombinedReport(filePath, outPath string, base ColumnSpec) error {
+	names, err := dataSheetNames(filePath)
+	if err != nil {
+		return err
+	}
+
+	var sections []sheetResults
+	var all []Student
+	prevSheet, prevColumns := dataSheet, columns
+	defer func() { dataSheet, columns = prevSheet, prevColumns }()
+	for _, name := range names {
+		dataSheet = name
+		if err := prepareLayout(filePath, base); err != nil {
+			return fmt.Errorf("sheet %s: %w", name, err)
+		}
+		results, err := processFile(filePath)
+		if errors.Is(err, errNoStudents) {
+			recordFinding(levelInfo, "Skipping sheet %s: %v", name, err)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("sheet %s: %w", name, err)
+		}
+		sections = append(sections, sheetResults{name, results})
+		all = append(all, results.Students...)
+	}
+
+	taken := append([]string{contentsSheet}, names...)
+	for i, section := range sections {
+		if strings.EqualFold(section.name, contentsSheet) {
+			sections[i].name = uniqueSheetName(section.name, "sheet", taken)
+			taken = append(taken, sections[i].name)
+		}
+	}
+	combined := Results{Students: all}
+	combined.setAggregate(aggregate(all).Result())
+	sections = append(sections, sheetResults{uniqueSheetName(aggregateSheet, "combined", taken), combined})
+
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName(f.GetSheetName(0), contentsSheet); err != nil {
+		return err
+	}
+	f.SetSheetRow(contentsSheet, "A1", &[]any{"Sheet", "Students", "Average Total"})
+	for i, section := range sections {
+		name := section.name
+		if _, err := f.NewSheet(name); err != nil {
+			return fmt.Errorf("sheet %s: %w", name, err)
+		}
+		if err := writeStatsSheet(f, name, section.results); err != nil {
+			return fmt.Errorf("sheet %s: %w", name, err)
+		}
+
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		f.SetSheetRow(contentsSheet, cell, &[]any{name, section.results.TotalCount,
+			statCell(section.results.TotalSum / float64(section.results.TotalCount))})
+		f.SetCellHyperLink(contentsSheet, cell, fmt.Sprintf("'%s'!A1", name), "Location")
+	}
+	return f.SaveAs(outPath)
+}
+
+// Returns name if no sheet in taken has it, ignoring case as Excel does, or else name
+// tagged " (tag)", " (tag 2)" and so on, cut short to fit Excel's sheet name limit
+func uniqueSheetName(name, tag string, taken []string) string {
+	candidate := name
+	for n := 1; slices.ContainsFunc(taken, func(t string) bool { return strings.EqualFold(t, candidate) }); n++ {
+		suffix := fmt.Sprintf(" (%s)", tag)
+		if n > 1 {
+			suffix = fmt.Sprintf(" (%s %d)", tag, n)
+		}
+		runes := []rune(name)
+		candidate = string(runes[:min(len(runes), maxSheetNameLength-len(suffix))]) + suffix
+	}
+	return candidate
+}
+
+// Writes the student count, per-component statistics and branch averages of one result set
+func writeStatsSheet(f *excelize.File, sheet string, results Results) error {
+	var rows [][]any
+	rows = append(rows,
+		[]any{"Students", results.TotalCount},
+		[]any{"Average Total", statCell(results.TotalSum / float64(results.TotalCount))},
+		nil,
+		[]any{"Component", "Mean", "Median", "Std Dev", "Min", "Max"},
+	)
+	for _, comp := range reportComponents {
+		values := make([]float64, len(results.Students))
+		for i, s := range results.Students {
+			values[i] = comp.getVal(s)
+		}
+		row := []any{comp.name(), "", "", "", "", ""}
+		if len(values) > 0 {
+			row = []any{comp.name(), statCell(mean(values)), statCell(median(values)), statCell(stddev(values)),
+				statCell(slices.Min(values)), statCell(slices.Max(values))}
+		}
+		rows = append(rows, row)
+	}
+	rows = append(rows, nil, []any{"Branch", "Name", "Students", "Average Total"})
+	for _, branch := range sortedBranches(results.BranchSums) {
+		count := results.BranchCounts[branch]
+		rows = append(rows, []any{branch, branchName(branch), count, statCell(results.BranchSums[branch] / float64(count))})
+	}
+
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns a statistic rounded for the report, or a blank cell when it is undefined
+func statCell(v float64) any {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return ""
+	}
+	return round2(v)
+}
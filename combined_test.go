@@ -0,0 +1,70 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestCombinedReportPreparesEachSheetsLayout(t *testing.T) {
+	resetState(t)
+	indexed := func(row []any) []any { return append([]any{"#"}, row...) }
+	in := writeTemp(t, "terms.xlsx", buildSheets(t, map[string][][]any{
+		aggregateSheet: {fixtureHeader, fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90)},
+		aggregateSheet + " (combined)": {
+			indexed(fixtureHeader),
+			indexed(fixtureRow("1002", "2024A7PS0002", 20, 55, 45, 20, 80)),
+		},
+	}))
+	out := filepath.Join(t.TempDir(), "combined.xlsx")
+	if err := writeCombinedReport(in, out, columns); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := excelize.OpenFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := f.GetRows(contentsSheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]string{}
+	for _, row := range rows[1:] {
+		counts[row[0]] = row[1]
+	}
+	want := map[string]string{aggregateSheet: "1", aggregateSheet + " (combined)": "1", aggregateSheet + " (combined 2)": "2"}
+	for name, count := range want {
+		if counts[name] != count {
+			t.Errorf("contents row %s = %q students, want %s (rows %v)", name, counts[name], count, rows)
+		}
+	}
+}
+
+func TestUniqueSheetName(t *testing.T) {
+	long := strings.Repeat("x", maxSheetNameLength)
+	tests := []struct {
+		name  string
+		taken []string
+		want  string
+	}{
+		{"All Sheets", []string{"Sheet1"}, "All Sheets"},
+		{"All Sheets", []string{"all sheets"}, "All Sheets (combined)"},
+		{"All Sheets", []string{"All Sheets", "All Sheets (combined)"}, "All Sheets (combined 2)"},
+		{long, []string{long}, strings.Repeat("x", maxSheetNameLength-len(" (combined)")) + " (combined)"},
+	}
+	for _, tt := range tests {
+		got := uniqueSheetName(tt.name, "combined", tt.taken)
+		if got != tt.want {
+			t.Errorf("uniqueSheetName(%q, %v) = %q, want %q", tt.name, tt.taken, got, tt.want)
+		}
+		if utf8.RuneCountInString(got) > maxSheetNameLength || slices.Contains(tt.taken, got) {
+			t.Errorf("uniqueSheetName(%q, %v) = %q, which Excel would reject", tt.name, tt.taken, got)
+		}
+	}
+}
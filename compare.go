@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Number of students listed as the biggest rank movers
+const rankMoverCount = 10
+
+// A student's overall rank in an earlier and the current run
+type rankChange struct {
+	EmpID  string
+	Before int
+	After  int
+}
+
+// Places moved up from the earlier run, negative when the student dropped
+func (c rankChange) moved() int {
+	return c.Before - c.After
+}
+
+// Matches students of two runs by EmpID and returns their rank changes, biggest movers
+// first, along with the EmpIDs only present in one of the runs
+func rankChanges(before, after []Student) (changes []rankChange, onlyBefore, onlyAfter []string) {
+	beforeRanks := overallRanks(before)
+	rankOf := make(map[string]int, len(before))
+	for i, s := range before {
+		rankOf[s.EmpID] = beforeRanks[i]
+	}
+
+	afterRanks := overallRanks(after)
+	seen := make(map[string]bool, len(after))
+	for i, s := range after {
+		seen[s.EmpID] = true
+		if rank, ok := rankOf[s.EmpID]; ok {
+			changes = append(changes, rankChange{EmpID: s.EmpID, Before: rank, After: afterRanks[i]})
+		} else {
+			onlyAfter = append(onlyAfter, s.EmpID)
+		}
+	}
+	for _, s := range before {
+		if !seen[s.EmpID] {
+			onlyBefore = append(onlyBefore, s.EmpID)
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		a, b := abs(changes[i].moved()), abs(changes[j].moved())
+		if a != b {
+			return a > b
+		}
+		return changes[i].After < changes[j].After
+	})
+	return changes, onlyBefore, onlyAfter
+}
+
+// Prints the students whose overall rank moved most since an earlier run
+func printRankChanges(w io.Writer, before, after []Student, label string) {
+	fmt.Fprintln(w, "\n"+bold("======================================"))
+	fmt.Fprintln(w, bold("Rank Changes Since "+label))
+
+	changes, onlyBefore, onlyAfter := rankChanges(before, after)
+	unchanged := 0
+	for _, c := range changes {
+		if c.moved() == 0 {
+			unchanged++
+		}
+	}
+	for i, c := range changes[:min(rankMoverCount, len(changes))] {
+		if c.moved() == 0 {
+			break
+		}
+		direction := "up"
+		if c.moved() < 0 {
+			direction = "down"
+		}
+		line := fmt.Sprintf("%s: %s - rank %d -> %d (%s %d)", idLabel, c.EmpID, c.Before, c.After, direction, abs(c.moved()))
+		if i == 0 {
+			line = highlight(line)
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintf(w, "%d of %d students kept their rank\n", unchanged, len(changes))
+	if len(onlyBefore) > 0 {
+		fmt.Fprintf(w, "Excluded, only in %s: %s\n", label, strings.Join(onlyBefore, ", "))
+	}
+	if len(onlyAfter) > 0 {
+		fmt.Fprintf(w, "Excluded, only in this run: %s\n", strings.Join(onlyAfter, ", "))
+	}
+}
+
+// Returns the absolute value of an integer
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// A student's rank, Total and component changes between two runs, as served by POST /diff
+type StudentChange struct {
+	EmpID            string             `json:"empID"`
+	RankBefore       int                `json:"rankBefore"`
+	RankAfter        int                `json:"rankAfter"`
+	RankMoved        int                `json:"rankMoved"` // places moved up, negative when the student dropped
+	TotalBefore      float64            `json:"totalBefore"`
+	TotalAfter       float64            `json:"totalAfter"`
+	ComponentChanges map[string]float64 `json:"componentChanges"` // after minus before, keyed by component key
+}
+
+// The changes between a baseline and a newer run
+type RunDiff struct {
+	Changes      []StudentChange `json:"changes"`
+	OnlyBaseline []string        `json:"onlyBaseline"`
+	OnlyNew      []string        `json:"onlyNew"`
+}
+
+// Compares two runs student by student, biggest rank movers first
+func diffRuns(before, after []Student) RunDiff {
+	byID := func(students []Student) map[string]Student {
+		m := make(map[string]Student, len(students))
+		for _, s := range students {
+			m[s.EmpID] = s
+		}
+		return m
+	}
+	beforeByID, afterByID := byID(before), byID(after)
+
+	changes, onlyBefore, onlyAfter := rankChanges(before, after)
+	diff := RunDiff{Changes: []StudentChange{}, OnlyBaseline: append([]string{}, onlyBefore...), OnlyNew: append([]string{}, onlyAfter...)}
+	for _, c := range changes {
+		b, a := beforeByID[c.EmpID], afterByID[c.EmpID]
+		deltas := make(map[string]float64)
+		for _, comp := range components {
+			deltas[comp.key] = round2(comp.getVal(a) - comp.getVal(b))
+		}
+		diff.Changes = append(diff.Changes, StudentChange{
+			EmpID:            c.EmpID,
+			RankBefore:       c.Before,
+			RankAfter:        c.After,
+			RankMoved:        c.moved(),
+			TotalBefore:      b.Total,
+			TotalAfter:       a.Total,
+			ComponentChanges: deltas,
+		})
+	}
+	return diff
+}
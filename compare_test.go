@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCompareReadsTheEarlierWorkbookWithItsOwnLayout(t *testing.T) {
+	resetState(t)
+	// The earlier export has a leading index column and renames a branch
+	indexed := func(row []any) []any { return append([]any{"#"}, row...) }
+	earlier := writeTemp(t, "earlier.xlsx", buildSheets(t, map[string][][]any{
+		"Sheet1": {
+			indexed(fixtureHeader),
+			indexed(fixtureRow("1001", "2024A7PS0001", 20, 55, 45, 20, 80)),
+			indexed(fixtureRow("1002", "2024A7PS0002", 25, 60, 50, 25, 90)),
+		},
+		branchSheetName: {{"Code", "Name"}, {"2024A7", "Renamed CSE"}},
+	}))
+	setFlag(t, compareFlag, earlier)
+
+	results, err := processFixture(t,
+		fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90),
+		fixtureRow("1002", "2024A7PS0002", 20, 55, 45, 20, 80),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := slices.Clone(findings)
+	layout := columns
+
+	var buf bytes.Buffer
+	if err := printResults(&buf, results, columns); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "rank 2 -> 1 (up 1)") {
+		t.Errorf("rank changes missing from the report:\n%s", out)
+	}
+	if branchMap["2024A7"] != "CSE 2024" {
+		t.Errorf("branch name after --compare = %q, want the current input's", branchMap["2024A7"])
+	}
+	if columns.EmpID != layout.EmpID {
+		t.Errorf("EmpID column after --compare = %d, want %d", columns.EmpID, layout.EmpID)
+	}
+	if !slices.Equal(findings, before) {
+		t.Errorf("findings after --compare = %v, want %v", findings, before)
+	}
+}
+
+func TestDiffRunsEncodesEmptyListsAsArrays(t *testing.T) {
+	students := []Student{{EmpID: "1001", Total: 250}}
+	data, err := json.Marshal(diffRuns(students, students))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"onlyBaseline":[]`, `"onlyNew":[]`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("diff = %s, want %s", data, want)
+		}
+	}
+}
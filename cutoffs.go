@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Proposes k-1 total-score cutoffs splitting students into k grade bands
+func suggestCutoffs(students []Student, k int, method string) []float64 {
+	totals := make([]float64, len(students))
+	for i, s := range students {
+		totals[i] = s.Total
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(totals)))
+
+	switch method {
+	case "gaps":
+		return gapCutoffs(totals, k)
+	case "quantile":
+		return quantileCutoffs(totals, k)
+	}
+	fatalf("Unknown cutoff method %q: must be \"gaps\" or \"quantile\"", method)
+	return nil
+}
+
+// Places cutoffs at the k-1 largest gaps between adjacent totals (totals sorted descending)
+func gapCutoffs(totals []float64, k int) []float64 {
+	type gap struct {
+		size  float64
+		upper float64
+	}
+	var gaps []gap
+	for i := 1; i < len(totals); i++ {
+		if totals[i-1] > totals[i] {
+			gaps = append(gaps, gap{size: totals[i-1] - totals[i], upper: totals[i-1]})
+		}
+	}
+	sort.SliceStable(gaps, func(i, j int) bool {
+		return gaps[i].size > gaps[j].size
+	})
+
+	var cutoffs []float64
+	for _, g := range gaps[:min(k-1, len(gaps))] {
+		cutoffs = append(cutoffs, g.upper)
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(cutoffs)))
+	return cutoffs
+}
+
+// Places cutoffs so each band holds roughly the same number of students (totals sorted descending)
+func quantileCutoffs(totals []float64, k int) []float64 {
+	var cutoffs []float64
+	for b := 1; b < k; b++ {
+		idx := int(math.Ceil(float64(b*len(totals))/float64(k))) - 1
+		cutoff := totals[idx]
+		if len(cutoffs) > 0 && cutoffs[len(cutoffs)-1] == cutoff {
+			continue
+		}
+		cutoffs = append(cutoffs, cutoff)
+	}
+	return cutoffs
+}
+
+// Counts the students in each band defined by descending cutoffs
+func bandSizes(students []Student, cutoffs []float64) []int {
+	sizes := make([]int, len(cutoffs)+1)
+	for _, s := range students {
+		band := len(cutoffs)
+		for i, c := range cutoffs {
+			if s.Total >= c {
+				band = i
+				break
+			}
+		}
+		sizes[band]++
+	}
+	return sizes
+}
+
+// Prints the suggested cutoffs and the resulting band sizes
+func printSuggestedCutoffs(w io.Writer, students []Student, k int, method string) {
+	if k < 2 {
+		fatalf("Invalid --suggest-cutoffs %d: need at least 2 bands", k)
+	}
+	if k > len(students) {
+		fatalf("Invalid --suggest-cutoffs %d: only %d students available", k, len(students))
+	}
+
+	cutoffs := suggestCutoffs(students, k, method)
+	sizes := bandSizes(students, cutoffs)
+
+	fmt.Fprintln(w, "======================================")
+	fmt.Fprintf(w, "Suggested Cutoffs (%d bands, %s)\n", k, method)
+	if len(cutoffs) < k-1 {
+		fmt.Fprintf(w, "Only %d distinct cutoffs found in the distribution\n", len(cutoffs))
+	}
+	for i, c := range cutoffs {
+		fmt.Fprintf(w, "Band %d: >= %.2f (%d students)\n", i+1, c, sizes[i])
+	}
+	if len(cutoffs) > 0 {
+		fmt.Fprintf(w, "Band %d: < %.2f (%d students)\n", len(cutoffs)+1, cutoffs[len(cutoffs)-1], sizes[len(cutoffs)])
+	}
+}
+
+// Label used for students below every grade cutoff
+const belowCutoffsGrade = "Below cutoffs"
+
+// The minimum total required for a grade
+type gradeCutoff struct {
+	Grade string
+	Min   float64
+}
+
+// Parses cutoffs such as A:260,B:220 and orders them from highest to lowest
+func parseCutoffs(spec string) ([]gradeCutoff, error) {
+	var cutoffs []gradeCutoff
+	for _, pair := range strings.Split(spec, ",") {
+		grade, raw, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid cutoff %q: expected GRADE:MIN", pair)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cutoff for %s: %v", grade, err)
+		}
+		cutoffs = append(cutoffs, gradeCutoff{Grade: strings.TrimSpace(grade), Min: value})
+	}
+	sort.SliceStable(cutoffs, func(i, j int) bool {
+		return cutoffs[i].Min > cutoffs[j].Min
+	})
+	return cutoffs, nil
+}
+
+// Returns the highest grade whose cutoff the total reaches
+func assignGrade(total float64, cutoffs []gradeCutoff) string {
+	for _, c := range cutoffs {
+		if total >= c.Min {
+			return c.Grade
+		}
+	}
+	return belowCutoffsGrade
+}
+
+// Grade points per grade from --grade-points, nil when not configured
+var gradePoints map[string]float64
+
+// Returns the mean grade point of the students' assigned grades
+func averageGradePoint(students []Student) float64 {
+	var sum float64
+	for _, s := range students {
+		sum += gradePoints[assignGrade(s.Total, gradeCutoffs)]
+	}
+	return sum / float64(len(students))
+}
+
+// Prints the grade distribution the cutoffs would produce and the students within margin of a cutoff
+func printCutoffSimulation(w io.Writer, students []Student, cutoffs []gradeCutoff, margin float64) {
+	counts := make(map[string]int)
+	for _, s := range students {
+		counts[assignGrade(s.Total, cutoffs)]++
+	}
+
+	fmt.Fprintln(w, "======================================")
+	fmt.Fprintln(w, "Simulated Grade Distribution")
+	grades := append([]gradeCutoff{}, cutoffs...)
+	grades = append(grades, gradeCutoff{Grade: belowCutoffsGrade})
+	for _, c := range grades {
+		fmt.Fprintf(w, "%s: %d students (%.2f%%)\n", c.Grade, counts[c.Grade], 100*float64(counts[c.Grade])/float64(len(students)))
+	}
+
+	fmt.Fprintln(w, "\n======================================")
+	fmt.Fprintf(w, "Students Within %.2f Marks of a Cutoff\n", margin)
+	sorted := sortByComponent(students, func(s Student) float64 { return s.Total })
+	found := false
+	for _, s := range sorted {
+		for _, c := range cutoffs {
+			if delta := s.Total - c.Min; math.Abs(delta) <= margin {
+				fmt.Fprintf(w, "%s: %s - %.2f (%+.2f from %s cutoff %.2f)\n", idLabel, s.EmpID, s.Total, delta, c.Grade, c.Min)
+				found = true
+			}
+		}
+	}
+	if !found {
+		fmt.Fprintln(w, "None")
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const resultsSchema = `CREATE TABLE IF NOT EXISTS results (
+	run_label   TEXT NOT NULL,
+	run_at      TEXT NOT NULL,
+	emp_id      TEXT NOT NULL,
+	branch      TEXT NOT NULL,
+	quiz        REAL NOT NULL,
+	mid_sem     REAL NOT NULL,
+	lab_test    REAL NOT NULL,
+	weekly_labs REAL NOT NULL,
+	compre      REAL NOT NULL,
+	total       REAL NOT NULL,
+	PRIMARY KEY (run_label, emp_id)
+)`
+
+const upsertResult = `INSERT INTO results
+	(run_label, run_at, emp_id, branch, quiz, mid_sem, lab_test, weekly_labs, compre, total)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (run_label, emp_id) DO UPDATE SET
+		run_at = excluded.run_at, branch = excluded.branch, quiz = excluded.quiz,
+		mid_sem = excluded.mid_sem, lab_test = excluded.lab_test, weekly_labs = excluded.weekly_labs,
+		compre = excluded.compre, total = excluded.total`
+
+// Appends the run's per-student records to the SQLite database at dbPath,
+// replacing any records previously stored under the same run label
+func storeRun(dbPath, runLabel string, students []Student) error {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(resultsSchema); err != nil {
+		return fmt.Errorf("create schema: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(upsertResult)
+	if err != nil {
+		return fmt.Errorf("prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	runAt := time.Now().UTC().Format(time.RFC3339)
+	for _, s := range students {
+		if _, err := stmt.Exec(runLabel, runAt, s.EmpID, s.Branch,
+			s.Quiz, s.MidSem, s.LabTest, s.WeeklyLabs, s.Compre, s.Total); err != nil {
+			return fmt.Errorf("insert EmpID %s: %w", s.EmpID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+const historyQuery = `SELECT run_label, run_at, branch, quiz, mid_sem, lab_test, weekly_labs, compre, total
+	FROM results WHERE emp_id = ? AND run_at >= ? ORDER BY run_at, run_label`
+
+// A student's stored marks in one run
+type historyEntry struct {
+	RunLabel string
+	RunAt    time.Time
+	Student  Student
+}
+
+// Loads a student's records from every run stored at or after since, oldest first
+func loadHistory(dbPath, empID string, since time.Time) ([]historyEntry, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(historyQuery, empID, since.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, fmt.Errorf("query history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []historyEntry
+	for rows.Next() {
+		var e historyEntry
+		var runAt string
+		s := &e.Student
+		if err := rows.Scan(&e.RunLabel, &runAt, &s.Branch, &s.Quiz, &s.MidSem, &s.LabTest, &s.WeeklyLabs, &s.Compre, &s.Total); err != nil {
+			return nil, fmt.Errorf("read history: %w", err)
+		}
+		s.EmpID = empID
+		if e.RunAt, err = time.Parse(time.RFC3339, runAt); err != nil {
+			return nil, fmt.Errorf("parse run time %q: %w", runAt, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Prints a student's marks in each stored run and how every component moved from the
+// first run to the last
+func printHistory(w io.Writer, empID string, entries []historyEntry) {
+	fmt.Fprintln(w, bold("======================================"))
+	fmt.Fprintln(w, bold(fmt.Sprintf("Score History for %s %s", idLabel, empID)))
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No stored runs")
+		return
+	}
+
+	fmt.Fprintf(w, "%-20s %-25s", "Run", "Stored At")
+	for _, comp := range components {
+		fmt.Fprintf(w, " %12s", comp.label)
+	}
+	fmt.Fprintln(w)
+	for _, e := range entries {
+		fmt.Fprintf(w, "%-20s %-25s", e.RunLabel, e.RunAt.In(reportLocation).Format(time.RFC3339))
+		for _, comp := range components {
+			fmt.Fprintf(w, " %12.2f", comp.getVal(e.Student))
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(entries) < 2 {
+		return
+	}
+	first, last := entries[0].Student, entries[len(entries)-1].Student
+	fmt.Fprintln(w, "\n"+bold(fmt.Sprintf("Trend over %d runs", len(entries))))
+	for _, comp := range components {
+		from, to := comp.getVal(first), comp.getVal(last)
+		trend := "steady"
+		if to > from {
+			trend = "improving"
+		} else if to < from {
+			trend = "declining"
+		}
+		fmt.Fprintf(w, "%s: %.2f -> %.2f (%+.2f, %s)\n", comp.name(), from, to, to-from, trend)
+	}
+}
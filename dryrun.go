@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// A file or other output the run would produce
+type artifact struct {
+	path   string
+	format string
+	rows   int
+}
+
+// Prints the artifacts the requested exports would produce, at the paths exportPath gives
+// them, with their formats and estimated row counts, without writing any of them
+func printPlannedArtifacts(w io.Writer, filePath string, results Results, exportPath func(string) string) error {
+	var planned []artifact
+	add := func(path, format string, rows int) {
+		planned = append(planned, artifact{exportPath(path), format, rows})
+	}
+
+	if *auditLogFlag != "" {
+		add(*auditLogFlag, "jsonl", auditEventCount)
+	}
+	if *webhookFlag != "" {
+		planned = append(planned, artifact{*webhookFlag, "webhook POST", 1})
+	}
+	if *dbFlag != "" {
+		runLabel := *runLabelFlag
+		if runLabel == "" {
+			runLabel = filepath.Base(filePath)
+		}
+		planned = append(planned, artifact{*dbFlag, fmt.Sprintf("sqlite (run %q)", runLabel), len(results.Students)})
+	}
+	if path := *dumpOutFlag; path != "" {
+		format, _ := resolveDumpFormat(path, *dumpFormatFlag)
+		add(path, format, len(results.Students))
+	}
+	if *perBranchDirFlag != "" {
+		groups := studentsByBranch(results.Students)
+		for _, branch := range sortedBranches(groups) {
+			planned = append(planned, artifact{filepath.Join(exportPath(*perBranchDirFlag), branch+".csv"), "csv", len(groups[branch])})
+		}
+	}
+	if *dumpDiscFlag != "" {
+		add(*dumpDiscFlag, "csv", len(discrepantStudents(results.Students)))
+	}
+	if *matrixFlag != "" {
+		add(*matrixFlag, "csv", len(results.Students))
+	}
+	if *chartFlag != "" {
+		add(*chartFlag, strings.TrimPrefix(strings.ToLower(filepath.Ext(*chartFlag)), "."), len(studentsByBranch(results.Students)))
+	}
+	if *reportCardsFlag != "" {
+		names, err := reportCardNames(results.Students, *cardFormatFlag)
+		if err != nil {
+			return fmt.Errorf("plan report cards: %w", err)
+		}
+		for _, name := range names {
+			planned = append(planned, artifact{filepath.Join(exportPath(*reportCardsFlag), name), *cardFormatFlag, 1})
+		}
+	}
+	if *summaryJSONFlag != "" {
+		add(*summaryJSONFlag, "json", 1)
+	}
+	if *skippedOutFlag != "" {
+		add(*skippedOutFlag, "csv", len(results.SkippedRows))
+	}
+	if *saveBaseFlag != "" {
+		add(*saveBaseFlag, "json", 1)
+	}
+	if *branchSummaryFlag && *branchSummaryOutFlag != "" {
+		add(*branchSummaryOutFlag, "csv", len(branchSummaryRows(results.Students, *branchMetric, *minBranchSize)))
+	}
+	if *profileFlag != "" {
+		planned = append(planned, artifact{*profileFlag, "CPU profile", 1})
+	}
+	if *memProfileFlag != "" {
+		planned = append(planned, artifact{*memProfileFlag, "heap profile", 1})
+	}
+	return printArtifacts(w, planned)
+}
+
+// Prints the planned artifacts under the dry-run banner
+func printArtifacts(w io.Writer, planned []artifact) error {
+	fmt.Fprintln(w, bold("======================================"))
+	fmt.Fprintln(w, bold("Dry Run: Planned Artifacts"))
+	if len(planned) == 0 {
+		fmt.Fprintln(w, "No artifacts would be written")
+		return nil
+	}
+	for _, a := range planned {
+		fmt.Fprintf(w, "%s (%s, ~%d rows)\n", a.path, a.format, a.rows)
+	}
+	_, err := fmt.Fprintln(w, nothingWritten())
+	return err
+}
+
+// Returns the closing line of a dry run. The plan itself still goes to --out when set,
+// so that is the one file a dry run writes.
+func nothingWritten() string {
+	if *outFlag != "" {
+		return "Nothing was written apart from this plan in " + *outFlag
+	}
+	return "Nothing was written"
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDryRunListsProfilesAndWritesOnlyThePlan(t *testing.T) {
+	resetState(t)
+	dir := t.TempDir()
+	out := filepath.Join(dir, "report.txt")
+	dump := filepath.Join(dir, "dump.csv")
+	cpu := filepath.Join(dir, "cpu.pprof")
+	setFlag(t, dryRunFlag, true)
+	setFlag(t, outFlag, out)
+	setFlag(t, dumpOutFlag, dump)
+	setFlag(t, profileFlag, cpu)
+
+	report := func(print func(w io.Writer) error) error { return writeReport(out, print) }
+	if err := runFile("testdata/sample.xlsx", false, report); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := string(data)
+	for _, want := range []string{dump + " (csv", cpu + " (CPU profile", "Nothing was written apart from this plan in " + out} {
+		if !strings.Contains(plan, want) {
+			t.Errorf("plan missing %q:\n%s", want, plan)
+		}
+	}
+	if strings.Contains(plan, out+" (") {
+		t.Errorf("plan lists the --out report it is written to:\n%s", plan)
+	}
+	for _, path := range []string{dump, cpu} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("dry run wrote %s", path)
+		}
+	}
+}
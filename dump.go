@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A column of the per-student dump
+type dumpField struct {
+	name  string
+	value func(Student) any
+}
+
+var nonFieldChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Returns the dump column name of a component, its label in snake case, e.g. "mid_sem"
+func componentField(comp component) string {
+	return strings.Trim(nonFieldChars.ReplaceAllString(strings.ToLower(comp.label), "_"), "_")
+}
+
+// Returns the columns available in the per-student dump, in default order, with one
+// column per component so merged components are exported as they are reported
+func dumpFields() []dumpField {
+	fields := []dumpField{
+		{"emp_id", func(s Student) any { return s.EmpID }},
+		{"branch", func(s Student) any { return s.Branch }},
+		{"branch_name", func(s Student) any { return branchName(s.Branch) }},
+	}
+	for _, comp := range components {
+		fields = append(fields, dumpField{componentField(comp), func(s Student) any { return comp.getVal(s) }})
+	}
+	return fields
+}
+
+// Returns the z-score columns, one per component making up the Total plus the composite,
+// available through --fields but not dumped by default
+func zScoreFields() []dumpField {
+	var fields []dumpField
+	for _, comp := range components {
+		if comp.key == "total" {
+			continue
+		}
+		fields = append(fields, dumpField{"z_" + componentField(comp), func(s Student) any { return zScore(s, comp) }})
+	}
+	return append(fields, dumpField{"z_composite", func(s Student) any { return compositeZ(s) }})
+}
+
+// Returns the fields with one week_N column per weekly lab, placed after the Weekly Labs
+// column or at the end when it is not selected
+func withWeekFields(fields []dumpField, weeks int) []dumpField {
+	weekly := "weekly_labs"
+	if comp, ok := componentByKey("weekly"); ok {
+		weekly = componentField(comp)
+	}
+
+	var weekFields []dumpField
+	for w := range weeks {
+		weekFields = append(weekFields, dumpField{fmt.Sprintf("week_%d", w+1), func(s Student) any {
+			if w < len(s.Weeks) {
+				return s.Weeks[w]
+			}
+			return 0.0
+		}})
+	}
+
+	at := len(fields)
+	for i, field := range fields {
+		if field.name == weekly {
+			at = i + 1
+		}
+	}
+	return slices.Concat(fields[:at], weekFields, fields[at:])
+}
+
+// Returns the fields followed by one <column>_rank column per component, holding each
+// student's rank in that component across the given students
+func withComponentRanks(fields []dumpField, students []Student) []dumpField {
+	fields = slices.Clone(fields)
+	for _, comp := range components {
+		byRow := make(map[int]int, len(students))
+		for i, rank := range ranksBy(students, comp) {
+			byRow[students[i].Row] = rank
+		}
+		fields = append(fields, dumpField{componentField(comp) + "_rank", func(s Student) any { return byRow[s.Row] }})
+	}
+	return fields
+}
+
+// Resolves a comma-separated field list into dump columns, or all columns when empty
+func selectDumpFields(spec string) ([]dumpField, error) {
+	if spec == "" {
+		return dumpFields(), nil
+	}
+
+	available := append(dumpFields(), zScoreFields()...)
+	var selected []dumpField
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, field := range available {
+			if field.name == name {
+				selected = append(selected, field)
+				found = true
+				break
+			}
+		}
+		if !found {
+			var valid []string
+			for _, field := range available {
+				valid = append(valid, field.name)
+			}
+			return nil, fmt.Errorf("unknown field %q (valid fields: %s)", name, strings.Join(valid, ", "))
+		}
+	}
+	return selected, nil
+}
+
+// Writers for each serialization of the per-student dump, keyed by format name
+var dumpWriters = map[string]func(w io.Writer, students []Student, fields []dumpField) error{
+	"csv": func(w io.Writer, students []Student, fields []dumpField) error {
+		return writeStudentsCSV(w, students, fields, ',')
+	},
+	"tsv": func(w io.Writer, students []Student, fields []dumpField) error {
+		return writeStudentsCSV(w, students, fields, '\t')
+	},
+	"json":  writeStudentsJSON,
+	"jsonl": writeStudentsJSONL,
+	"parquet": func(w io.Writer, students []Student, fields []dumpField) error {
+		return writeStudentsParquet(w, students, fields, reportTime.In(reportLocation))
+	},
+}
+
+// Returns format when set, otherwise the format named by the extension of path
+func resolveDumpFormat(path, format string) (string, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	if _, ok := dumpWriters[format]; !ok {
+		formats := slices.Sorted(maps.Keys(dumpWriters))
+		return "", fmt.Errorf("unsupported dump format %q: use %s, or a path ending in one of them", format, strings.Join(formats, ", "))
+	}
+	return format, nil
+}
+
+// Writes every student to path in the given format, or the one its extension names when
+// format is empty
+func dumpStudents(path, format string, students []Student, fields []dumpField) error {
+	format, err := resolveDumpFormat(path, format)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := dumpWriters[format](f, students, fields); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Writes students as delimited text with a header row of field names
+func writeStudentsCSV(out io.Writer, students []Student, fields []dumpField, comma rune) error {
+	w := csv.NewWriter(out)
+	w.Comma = comma
+	header := make([]string, len(fields))
+	for i, field := range fields {
+		header[i] = field.name
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for _, s := range students {
+		record := make([]string, len(fields))
+		for i, field := range fields {
+			record[i] = formatDumpValue(field.value(s))
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// Writes one CSV per branch into dir, named after the branch code, with students ranked
+// by Total. The rank column's header carries the branch's friendly name.
+func dumpPerBranch(dir string, students []Student, fields []dumpField) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	total, _ := componentByKey("total")
+	groups := studentsByBranch(students)
+	for _, branch := range sortedBranches(groups) {
+		sorted := rankByComponent(groups[branch], total)
+		ranks := overallRanks(sorted)
+
+		f, err := os.Create(filepath.Join(dir, branch+".csv"))
+		if err != nil {
+			return err
+		}
+		w := csv.NewWriter(f)
+		header := []string{fmt.Sprintf("rank (%s)", branchName(branch))}
+		for _, field := range fields {
+			header = append(header, field.name)
+		}
+		w.Write(header)
+		for i, s := range sorted {
+			record := []string{strconv.Itoa(ranks[i])}
+			for _, field := range fields {
+				record = append(record, formatDumpValue(field.value(s)))
+			}
+			w.Write(record)
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Writes students as a JSON array of objects whose keys follow the field order
+func writeStudentsJSON(w io.Writer, students []Student, fields []dumpField) error {
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for i, s := range students {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		if err := writeStudentObject(&buf, s, fields); err != nil {
+			return err
+		}
+	}
+	buf.WriteString("]\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Writes students as JSON Lines, one object per line
+func writeStudentsJSONL(out io.Writer, students []Student, fields []dumpField) error {
+	w := bufio.NewWriter(out)
+	var buf bytes.Buffer
+	for _, s := range students {
+		buf.Reset()
+		if err := writeStudentObject(&buf, s, fields); err != nil {
+			return err
+		}
+		buf.WriteString("\n")
+		w.Write(buf.Bytes())
+	}
+	return w.Flush()
+}
+
+// Appends one student as a JSON object whose keys follow the field order
+func writeStudentObject(buf *bytes.Buffer, s Student, fields []dumpField) error {
+	buf.WriteString("{")
+	for j, field := range fields {
+		if j > 0 {
+			buf.WriteString(",")
+		}
+		key, _ := json.Marshal(field.name)
+		value, err := json.Marshal(field.value(s))
+		if err != nil {
+			return err
+		}
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(value)
+	}
+	buf.WriteString("}")
+	return nil
+}
+
+// Formats a dump value for CSV, keeping two-decimal precision for marks
+func formatDumpValue(v any) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	case string:
+		return v
+	}
+	return fmt.Sprint(v)
+}
+
+// Writes skipped rows to a CSV file as row number, reason, and the original cells
+func writeSkippedRows(path string, rows []SkippedRow) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if err := w.Write([]string{"row", "reason", "cells"}); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		record := append([]string{strconv.Itoa(r.Row), r.Reason}, r.Cells...)
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Writes a CSV matrix of branch, ID and every component, one row per student, with marks
+// as percentages of each component's maximum when normalized is set
+func writeComponentMatrix(path string, students []Student, normalized bool) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	header := []string{"branch", idLabel}
+	for _, comp := range components {
+		header = append(header, comp.label)
+	}
+	w.Write(header)
+	for _, s := range students {
+		record := []string{s.Branch, s.EmpID}
+		for _, comp := range components {
+			value := comp.getVal(s)
+			if normalized {
+				value = 100 * value / comp.max
+			}
+			record = append(record, formatDumpValue(value))
+		}
+		w.Write(record)
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Writes the students whose sheet Total disagrees with the component sum beyond the
+// tolerance, with both totals, the delta and the components, so the source can be fixed
+func writeDiscrepancies(path string, students []Student) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	var parts []component
+	for _, comp := range components {
+		if comp.key != "total" {
+			parts = append(parts, comp)
+		}
+	}
+	header := []string{"row", idLabel, "branch"}
+	for _, comp := range parts {
+		header = append(header, componentField(comp))
+	}
+	w.Write(append(header, "sheet_total", "computed_total", "delta"))
+	for _, s := range discrepantStudents(students) {
+		computed := computedTotal(s)
+		record := []string{strconv.Itoa(s.Row), s.EmpID, s.Branch}
+		for _, comp := range parts {
+			record = append(record, formatDumpValue(comp.getVal(s)))
+		}
+		w.Write(append(record, formatDumpValue(s.Total), formatDumpValue(computed), formatDumpValue(s.Total-computed)))
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Returns the students whose Total disagrees with the component sum beyond the tolerance
+func discrepantStudents(students []Student) []Student {
+	var discrepant []Student
+	for _, s := range students {
+		if !isWithinTolerance(computedTotal(s), s.Total) {
+			discrepant = append(discrepant, s)
+		}
+	}
+	return discrepant
+}
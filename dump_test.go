@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Processes two students whose totals and ranks differ
+func dumpFixture(t *testing.T) Results {
+	t.Helper()
+	results, err := processFixture(t,
+		fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90),
+		fixtureRow("1002", "2024A3PS0002", 20, 55, 45, 20, 80))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return results
+}
+
+func TestDumpStudentsFormats(t *testing.T) {
+	results := dumpFixture(t)
+	fields, err := selectDumpFields("emp_id,total")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"csv":   "emp_id,total\n1001,250.00\n1002,220.00\n",
+		"tsv":   "emp_id\ttotal\n1001\t250.00\n1002\t220.00\n",
+		"json":  `[{"emp_id":"1001","total":250},{"emp_id":"1002","total":220}]` + "\n",
+		"jsonl": `{"emp_id":"1001","total":250}` + "\n" + `{"emp_id":"1002","total":220}` + "\n",
+	}
+	for format, text := range want {
+		path := filepath.Join(t.TempDir(), "dump.out")
+		if err := dumpStudents(path, format, results.Students, fields); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != text {
+			t.Errorf("%s dump = %q, want %q", format, got, text)
+		}
+	}
+}
+
+func TestDumpStudentsParquetFields(t *testing.T) {
+	results := dumpFixture(t)
+	fields, err := selectDumpFields("emp_id,total")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields = withComponentRanks(fields, results.Students)
+
+	path := filepath.Join(t.TempDir(), "dump.parquet")
+	if err := dumpStudents(path, "", results.Students, fields); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := parquet.OpenFile(f, info.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var columns []string
+	for _, field := range file.Schema().Fields() {
+		columns = append(columns, field.Name())
+	}
+	wantColumns := []string{parquetRunAtColumn}
+	for _, field := range fields {
+		wantColumns = append(wantColumns, field.name)
+	}
+	slices.Sort(wantColumns)
+	if !slices.Equal(columns, wantColumns) {
+		t.Errorf("columns = %v, want %v", columns, wantColumns)
+	}
+
+	reader := parquet.NewReader(file)
+	var rows []map[string]any
+	for range file.NumRows() {
+		row := map[string]any{}
+		if err := reader.Read(&row); err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) != 2 || rows[0]["emp_id"] != "1001" || rows[1]["total"] != 220.0 || rows[0]["total_rank"] != int64(1) {
+		t.Errorf("rows = %v", rows)
+	}
+}
+
+func TestResolveDumpFormat(t *testing.T) {
+	if format, err := resolveDumpFormat("out.JSONL", ""); err != nil || format != "jsonl" {
+		t.Errorf("format from extension = %q, %v", format, err)
+	}
+	if format, err := resolveDumpFormat("out.txt", "tsv"); err != nil || format != "tsv" {
+		t.Errorf("explicit format = %q, %v", format, err)
+	}
+	_, err := resolveDumpFormat("out.xml", "")
+	if err == nil || !strings.Contains(err.Error(), "csv, json, jsonl, parquet, tsv") {
+		t.Errorf("unsupported format error = %v", err)
+	}
+}
+
+func TestDumpFieldsFollowMergedComponents(t *testing.T) {
+	keepComponents(t)
+	if err := mergeComponents("labtest,weekly:Lab", "sum"); err != nil {
+		t.Fatal(err)
+	}
+	results, err := processFixture(t, fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "dump.csv")
+	if err := dumpStudents(path, "", results.Students, dumpFields()); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "emp_id,branch,branch_name,quiz,mid_sem,lab,compre,total\n1001,2024A7,CSE 2024,25.00,60.00,75.00,90.00,250.00\n"
+	if string(got) != want {
+		t.Errorf("dump = %q, want %q", got, want)
+	}
+
+	if _, err := selectDumpFields("emp_id,z_lab"); err != nil {
+		t.Errorf("z-score of the merged component: %v", err)
+	}
+
+	var full bytes.Buffer
+	if err := printFullJSON(&full, results); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(full.String(), `"components":{"compre":90,"lab":75,"mid_sem":60,"quiz":25,"total":250}`) {
+		t.Errorf("json-full students lack the merged component: %s", full.String())
+	}
+}
+
+func TestDumpFieldsFollowComponentAliases(t *testing.T) {
+	keepComponents(t)
+	if err := setComponentLabels("quiz=Assignments,weekly=Lab Sheets"); err != nil {
+		t.Fatal(err)
+	}
+	results, err := processFixture(t, fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results.Students[0].Weeks = []float64{12, 13}
+
+	fields, err := selectDumpFields("emp_id,assignments,lab_sheets,z_assignments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields = withComponentRanks(withWeekFields(fields, 2), results.Students)
+	var names []string
+	for _, field := range fields {
+		names = append(names, field.name)
+	}
+	want := []string{"emp_id", "assignments", "lab_sheets", "week_1", "week_2", "z_assignments",
+		"assignments_rank", "mid_sem_rank", "lab_test_rank", "lab_sheets_rank", "compre_rank", "total_rank"}
+	if !slices.Equal(names, want) {
+		t.Errorf("fields = %v, want %v", names, want)
+	}
+
+	if _, err := selectDumpFields("quiz"); err == nil {
+		t.Error("the component's original column name is still accepted after relabelling")
+	}
+}
+
+func TestDiscrepanciesAndFullJSONUseTheRunLabels(t *testing.T) {
+	resetState(t)
+	results, err := processFixture(t, fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90))
+	if err != nil {
+		t.Fatal(err)
+	}
+	idLabel = "Roll No"
+	setFlag(t, &aliasMembers, map[string][]string{"CSE": {"2024A7", "2021A7"}})
+	results.Students[0].Branch = "CSE"
+	results.Students[0].Total = 240
+
+	path := filepath.Join(t.TempDir(), "disc.csv")
+	if err := writeDiscrepancies(path, results.Students); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(got), "row,Roll No,branch,") {
+		t.Errorf("discrepancy header = %q, want the sheet's ID label", got)
+	}
+
+	var full bytes.Buffer
+	if err := printFullJSON(&full, results); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(full.String(), `"branchName":"2024A7, 2021A7"`) {
+		t.Errorf("json-full students lack the alias group's name: %s", full.String())
+	}
+}
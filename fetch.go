@@ -0,0 +1,93 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Default timeout for downloading a remote workbook
+const defaultFetchTimeout = 30 * time.Second
+
+// Reports whether path refers to a remote workbook
+func isURL(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// Environment variable read for the workbook password when --password is not given,
+// keeping the password out of shell history
+const passwordEnvVar = "GRADES_XLSX_PASSWORD"
+
+// Returns the workbook password from --password or the environment
+func workbookPassword() string {
+	if *passwordFlag != "" {
+		return *passwordFlag
+	}
+	return os.Getenv(passwordEnvVar)
+}
+
+// Opens a workbook from a local path or an http(s) URL, decrypting it when a password is set
+func openWorkbook(path string) (*excelize.File, error) {
+	if isODS(path) {
+		return nil, fmt.Errorf("%s is an OpenDocument spreadsheet, which this mode cannot modify; please export it as .xlsx", path)
+	}
+	f, err := openWorkbookWith(path, excelize.Options{Password: workbookPassword()})
+	if errors.Is(err, excelize.ErrWorkbookPassword) {
+		return nil, fmt.Errorf("incorrect password for %s (check --password or $%s)", path, passwordEnvVar)
+	}
+	if errors.Is(err, zip.ErrFormat) && workbookPassword() == "" {
+		return nil, fmt.Errorf("%w; if %s is password protected, set --password or $%s", err, path, passwordEnvVar)
+	}
+	return f, err
+}
+
+// Opens a local or remote workbook with the given options
+func openWorkbookWith(path string, opts excelize.Options) (*excelize.File, error) {
+	if !isURL(path) {
+		return excelize.OpenFile(path, opts)
+	}
+	body, err := download(path)
+	if err != nil {
+		return nil, err
+	}
+	return excelize.OpenReader(bytes.NewReader(body), opts)
+}
+
+// The most recently downloaded workbook, kept so that the several passes a run makes over
+// a remote input fetch it once and all read the same copy
+var lastDownload struct {
+	url  string
+	body []byte
+}
+
+// Returns the body of the workbook at url, downloading it unless it was the last one fetched
+func download(url string) ([]byte, error) {
+	if lastDownload.body != nil && lastDownload.url == url {
+		return lastDownload.body, nil
+	}
+
+	client := http.Client{Timeout: *timeoutFlag}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download %s: unexpected status %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", url, err)
+	}
+	lastDownload.url, lastDownload.body = url, body
+	return body, nil
+}
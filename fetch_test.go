@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRemoteWorkbookDownloadedOnce(t *testing.T) {
+	resetState(t)
+	body := readAll(t, buildWorkbook(t, fixtureHeader, fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90)))
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write(body)
+	}))
+	defer srv.Close()
+	t.Cleanup(func() { lastDownload.url, lastDownload.body = "", nil })
+
+	url := srv.URL + "/grades.xlsx"
+	if err := prepareLayout(url, defaultColumnSpec); err != nil {
+		t.Fatal(err)
+	}
+	results, err := processFile(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.TotalCount != 1 {
+		t.Errorf("TotalCount = %d, want 1", results.TotalCount)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("workbook downloaded %d times, want once", n)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Reports whether a student satisfies a --where expression
+type predicate func(Student) bool
+
+// Students must satisfy this predicate to enter the statistics, nil when --where is unset
+var studentFilter predicate
+
+// Parses a --where expression such as "total>250 and branch=2024A7".
+//
+//	expr       := andExpr { "or" andExpr }
+//	andExpr    := term { "and" term }
+//	term       := "(" expr ")" | comparison
+//	comparison := field op value
+//
+// Fields are the component keys and branch; op is one of = == != < <= > >=.
+func parseWhere(expr string) (predicate, error) {
+	tokens, err := tokenizeWhere(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &whereParser{tokens: tokens}
+	pred, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.tokens[p.pos], p.pos+1)
+	}
+	return pred, nil
+}
+
+// Splits an expression into identifiers, numbers, quoted strings, operators and parentheses
+func tokenizeWhere(expr string) ([]string, error) {
+	var tokens []string
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, string(r))
+			i++
+		case strings.ContainsRune("<>=!", r):
+			j := i + 1
+			if j < len(runes) && runes[j] == '=' {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		case r == '"' || r == '\'':
+			j := i + 1
+			for j < len(runes) && runes[j] != r {
+				j++
+			}
+			if j == len(runes) {
+				return nil, fmt.Errorf("unterminated string starting at %d", i+1)
+			}
+			tokens = append(tokens, string(runes[i:j+1]))
+			i = j + 1
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || strings.ContainsRune(".-_", runes[j])) {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q at %d", r, i+1)
+		}
+	}
+	return tokens, nil
+}
+
+// Recursive-descent parser over the tokens of a --where expression
+type whereParser struct {
+	tokens []string
+	pos    int
+}
+
+// Returns the current token, or "" at the end of input
+func (p *whereParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// Consumes and returns the current token
+func (p *whereParser) next() string {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+func (p *whereParser) parseOr() (predicate, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(s Student) bool { return l(s) || right(s) }
+	}
+	return left, nil
+}
+
+func (p *whereParser) parseAnd() (predicate, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "and") {
+		p.next()
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(s Student) bool { return l(s) && right(s) }
+	}
+	return left, nil
+}
+
+func (p *whereParser) parseTerm() (predicate, error) {
+	if p.peek() == "(" {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *whereParser) parseComparison() (predicate, error) {
+	field := strings.ToLower(p.next())
+	op := p.next()
+	value := strings.Trim(p.next(), `"'`)
+	if field == "" || op == "" || value == "" {
+		return nil, fmt.Errorf("incomplete comparison, expected field op value")
+	}
+	if op == "==" {
+		op = "="
+	}
+
+	if field == "branch" {
+		switch op {
+		case "=":
+			return func(s Student) bool { return s.Branch == value }, nil
+		case "!=":
+			return func(s Student) bool { return s.Branch != value }, nil
+		}
+		return nil, fmt.Errorf("branch only supports = and !=, got %q", op)
+	}
+
+	comp, ok := componentByKey(field)
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q (valid fields: branch, %s)", field, strings.Join(componentKeys(), ", "))
+	}
+	threshold, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be compared with a number, got %q", field, value)
+	}
+
+	var cmp func(a, b float64) bool
+	switch op {
+	case "=":
+		cmp = func(a, b float64) bool { return isWithinTolerance(a, b) }
+	case "!=":
+		cmp = func(a, b float64) bool { return !isWithinTolerance(a, b) }
+	case "<":
+		cmp = func(a, b float64) bool { return a < b }
+	case "<=":
+		cmp = func(a, b float64) bool { return a <= b }
+	case ">":
+		cmp = func(a, b float64) bool { return a > b }
+	case ">=":
+		cmp = func(a, b float64) bool { return a >= b }
+	default:
+		return nil, fmt.Errorf("unknown operator %q", op)
+	}
+	return func(s Student) bool { return cmp(comp.getVal(s), threshold) }, nil
+}
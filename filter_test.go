@@ -0,0 +1,102 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseWhere(t *testing.T) {
+	students := []Student{
+		{EmpID: "1001", Branch: "2024A7", Quiz: 25, Total: 250},
+		{EmpID: "1002", Branch: "2024A7", Quiz: 20, Total: 220},
+		{EmpID: "1003", Branch: "2024A3", Quiz: 28, Total: 281},
+		{EmpID: "1004", Branch: "2021A7", Quiz: 10, Total: 100},
+	}
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"total>250", []string{"1003"}},
+		{"total >= 250", []string{"1001", "1003"}},
+		{"total<220", []string{"1004"}},
+		{"total<=220", []string{"1002", "1004"}},
+		{"total=250", []string{"1001"}},
+		{"total==250.001", []string{"1001"}},
+		{"total!=250", []string{"1002", "1003", "1004"}},
+		{"branch=2024A7", []string{"1001", "1002"}},
+		{`branch != "2024A7"`, []string{"1003", "1004"}},
+		{"total>200 and branch=2024A7", []string{"1001", "1002"}},
+		{"quiz>=25 or total<150", []string{"1001", "1003", "1004"}},
+		{"branch=2024A3 or branch=2024A7 and quiz>22", []string{"1001", "1003"}},
+		{"(branch=2024A3 or branch=2024A7) and quiz<26", []string{"1001", "1002"}},
+		{"TOTAL>250 AND Branch='2024A3'", []string{"1003"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			pred, err := parseWhere(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, s := range students {
+				if pred(s) {
+					got = append(got, s.EmpID)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("matched %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWhereErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"", "incomplete comparison"},
+		{"total>", "incomplete comparison"},
+		{"grade>3", `unknown field "grade"`},
+		{"total>abc", "must be compared with a number"},
+		{"branch>2024A7", "branch only supports = and !="},
+		{"total ! 250", `unknown operator "!"`},
+		{"(total>250", "missing closing parenthesis"},
+		{"total>250 branch=2024A7", `unexpected "branch"`},
+		{`branch="2024A7`, "unterminated string"},
+		{"total>250 & quiz>20", "unexpected character '&'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := parseWhere(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWhereFiltersStudents(t *testing.T) {
+	resetState(t)
+	pred, err := parseWhere("total>=220 and branch=2024A7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	studentFilter = pred
+	t.Cleanup(func() { studentFilter = nil })
+
+	results, err := processReader(buildWorkbook(t, fixtureHeader,
+		fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90),
+		fixtureRow("1002", "2024A7PS0002", 15, 40, 30, 15, 60),
+		fixtureRow("1003", "2024A3PS0003", 28, 70, 55, 28, 100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := empIDs(results.Students); !slices.Equal(got, []string{"1001"}) {
+		t.Errorf("students = %v, want [1001]", got)
+	}
+	if results.TotalCount != 1 || results.BranchCounts["2024A3"] != 0 {
+		t.Errorf("filtered students counted: total %d, 2024A3 %d", results.TotalCount, results.BranchCounts["2024A3"])
+	}
+}
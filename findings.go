@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Severity levels for data findings
+const (
+	levelInfo    = "info"
+	levelWarning = "warning"
+	levelError   = "error"
+)
+
+// An issue noticed in the data while processing
+type Finding struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// Findings recorded during the run, in the order they were logged
+var findings []Finding
+
+// Logs a finding and records it for machine-readable reports; errors are shown in red
+func recordFinding(level, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	findings = append(findings, Finding{Level: level, Message: message})
+	if level == levelError && *logFormatFlag == "plain" {
+		message = alert(message)
+	}
+	slog.Log(context.Background(), slogLevel(level), message)
+}
+
+// Returns the recorded findings with the given level
+func findingsWithLevel(level string) []Finding {
+	var matched []Finding
+	for _, f := range findings {
+		if f.Level == level {
+			matched = append(matched, f)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Silences findings while the tests run; they are asserted through the findings slice
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// Header of the standard grade sheet, in defaultColumnSpec order
+var fixtureHeader = []any{"Sl", "Class No", "Emplid", "Campus ID", "Quiz (30)", "Mid-Sem (75)",
+	"Lab Test (60)", "Weekly Labs (30)", "Pre-Compre (195)", "Compre (105)", "Total (300)"}
+
+// Returns a standard-layout data row whose Total is the component sum
+func fixtureRow(empID, campusID string, quiz, midSem, labTest, weekly, compre float64) []any {
+	pre := quiz + midSem + labTest + weekly
+	return []any{1, 1, empID, campusID, quiz, midSem, labTest, weekly, pre, compre, pre + compre}
+}
+
+// Builds an in-memory .xlsx whose first sheet holds header followed by rows
+func buildWorkbook(t testing.TB, header []any, rows ...[]any) *bytes.Reader {
+	t.Helper()
+	return buildSheets(t, map[string][][]any{"Sheet1": append([][]any{header}, rows...)})
+}
+
+// Builds an in-memory .xlsx with a standard-layout first sheet holding rows and a
+// Branches sheet naming the given branch codes
+func buildBranchWorkbook(t testing.TB, names map[string]string, rows ...[]any) *bytes.Reader {
+	t.Helper()
+	branches := [][]any{{"Code", "Name"}}
+	for code, name := range names {
+		branches = append(branches, []any{code, name})
+	}
+	return buildSheets(t, map[string][][]any{"Sheet1": append([][]any{fixtureHeader}, rows...), branchSheetName: branches})
+}
+
+// Builds an in-memory .xlsx holding the given rows on each named sheet
+func buildSheets(t testing.TB, sheets map[string][][]any) *bytes.Reader {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+	for sheet, rows := range sheets {
+		if _, err := f.NewSheet(sheet); err != nil {
+			t.Fatalf("add sheet %s: %v", sheet, err)
+		}
+		for i, row := range rows {
+			cell, _ := excelize.CoordinatesToCellName(1, i+1)
+			if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+				t.Fatalf("write %s row %d: %v", sheet, i+1, err)
+			}
+		}
+	}
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+// Writes a standard-layout workbook holding rows to a temporary file and returns its path
+func writeFixture(t testing.TB, rows ...[]any) string {
+	t.Helper()
+	return writeWorkbook(t, fixtureHeader, rows...)
+}
+
+// Writes a workbook with the given header and rows to a temporary file and returns its path
+func writeWorkbook(t testing.TB, header []any, rows ...[]any) string {
+	t.Helper()
+	return writeTemp(t, "fixture.xlsx", buildWorkbook(t, header, rows...))
+}
+
+// Writes r to a file named name in a new temporary directory and returns its path
+func writeTemp(t testing.TB, name string, r io.Reader) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, readAll(t, r), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	return path
+}
+
+func readAll(t testing.TB, r io.Reader) []byte {
+	t.Helper()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	return data
+}
+
+// Processes an in-memory standard-layout workbook holding rows
+func processFixture(t testing.TB, rows ...[]any) (Results, error) {
+	t.Helper()
+	resetState(t)
+	return processReader(buildWorkbook(t, fixtureHeader, rows...))
+}
+
+// Restores the package state a run mutates once the test ends, and clears the findings
+func resetState(t testing.TB) {
+	t.Helper()
+	findings = nil
+	saved := saveRunState()
+	savedRange, savedRequired := dataRange, requiredComponents
+	t.Cleanup(func() {
+		saved.restore()
+		dataRange, requiredComponents = savedRange, savedRequired
+	})
+}
+
+// Restores the component table once the test ends, for tests that merge or relabel
+func keepComponents(t testing.TB) {
+	t.Helper()
+	saved, savedReport := slices.Clone(components), reportComponents
+	t.Cleanup(func() { components, reportComponents = saved, savedReport })
+}
+
+// Sets a flag value for the duration of the test
+func setFlag[T any](t testing.TB, flag *T, value T) {
+	t.Helper()
+	saved := *flag
+	*flag = value
+	t.Cleanup(func() { *flag = saved })
+}
+
+// Returns the messages of the recorded findings with the given level
+func findingMessages(level string) []string {
+	var messages []string
+	for _, f := range findingsWithLevel(level) {
+		messages = append(messages, f.Message)
+	}
+	return messages
+}
+
+// Returns the EmpIDs of the students, in order
+func empIDs(students []Student) []string {
+	ids := make([]string, len(students))
+	for i, s := range students {
+		ids[i] = s.EmpID
+	}
+	return ids
+}
+
+// Returns a fixture of n valid rows spread over a few branches, for benchmarks
+func generatedRows(n int) [][]any {
+	codes := []string{"2024A7", "2024A3", "2024A4", "2021A7"}
+	rows := make([][]any, n)
+	for i := range rows {
+		id := fmt.Sprintf("%d", 100000+i)
+		rows[i] = fixtureRow(id, fmt.Sprintf("%sPS%04d", codes[i%len(codes)], i%10000),
+			float64(i%31), float64(i%76), float64(i%61), float64(i%31), float64(i%106))
+	}
+	return rows
+}
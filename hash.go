@@ -0,0 +1,47 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"time"
+)
+
+// Hex digits kept from the SHA-256 digest in the report hash
+const reportHashLength = 12
+
+// Returns a short hash of the parsed students and the key statistics, so two runs can be
+// compared at a glance. Values are written at report precision and branches in sorted order.
+func reportHash(results Results) string {
+	h := sha256.New()
+	for _, s := range results.Students {
+		fmt.Fprintf(h, "%s|%s|%.2f|%.2f|%.2f|%.2f|%.2f|%.2f\n",
+			s.EmpID, s.Branch, s.Quiz, s.MidSem, s.LabTest, s.WeeklyLabs, s.Compre, s.Total)
+	}
+	writeKeyStats(h, results)
+	if *hashTimestampFlag {
+		fmt.Fprintf(h, "generated=%s\n", generatedAt())
+	}
+	return hex.EncodeToString(h.Sum(nil))[:reportHashLength]
+}
+
+// Time the report was generated, shown in --tz
+var reportTime = time.Now()
+
+// Time zone of report timestamps, set by --tz
+var reportLocation = time.Local
+
+// Returns the report generation time in --tz as RFC 3339
+func generatedAt() string {
+	return reportTime.In(reportLocation).Format(time.RFC3339)
+}
+
+// Writes the student count, overall average and branch averages to the hash
+func writeKeyStats(h hash.Hash, results Results) {
+	fmt.Fprintf(h, "count=%d\n", results.TotalCount)
+	fmt.Fprintf(h, "overall=%.2f\n", results.TotalSum/float64(results.TotalCount))
+	for _, branch := range sortedBranches(results.BranchSums) {
+		fmt.Fprintf(h, "%s=%.2f/%d\n", branch, results.BranchSums[branch]/float64(results.BranchCounts[branch]), results.BranchCounts[branch])
+	}
+}
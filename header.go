@@ -0,0 +1,56 @@
+package main
+
+import (
+	"github.com/xuri/excelize/v2"
+)
+
+// A merged block of cells with its label, by 1-based inclusive coordinates as in
+// spreadsheet cell names
+type mergedRange struct {
+	startCol, startRow int
+	endCol, endRow     int
+	value              string
+}
+
+// Copies the label of every merged cell that starts on the header row across all the
+// columns it spans, since the row reader only reports it in the first cell. The row is
+// 0-based; headers without merged cells are returned unchanged.
+func fillMergedHeader(f *excelize.File, sheetName string, r int, header []string) []string {
+	merges, err := f.GetMergeCells(sheetName)
+	if err != nil || len(merges) == 0 {
+		return header
+	}
+
+	var ranges []mergedRange
+	for _, m := range merges {
+		startCol, startRow, err := excelize.CellNameToCoordinates(m.GetStartAxis())
+		if err != nil {
+			continue
+		}
+		endCol, endRow, err := excelize.CellNameToCoordinates(m.GetEndAxis())
+		if err != nil {
+			continue
+		}
+		ranges = append(ranges, mergedRange{startCol, startRow, endCol, endRow, m.GetCellValue()})
+	}
+	return fillMerges(header, r, ranges)
+}
+
+// Fills the blank cells of the 0-based header row r that lie in one of merges with the
+// merge's label, extending the row to the last column a merge covers
+func fillMerges(header []string, r int, merges []mergedRange) []string {
+	for _, m := range merges {
+		if r+1 < m.startRow || r+1 > m.endRow {
+			continue
+		}
+		for len(header) < m.endCol {
+			header = append(header, "")
+		}
+		for c := m.startCol - 1; c < m.endCol; c++ {
+			if header[c] == "" {
+				header[c] = m.value
+			}
+		}
+	}
+	return header
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestMergedHeaderFilledPastTheLastReadCell(t *testing.T) {
+	resetState(t)
+	f := excelize.NewFile()
+	defer f.Close()
+	header := slices.Concat(fixtureHeader, []any{"Weeks"})
+	if err := f.SetSheetRow("Sheet1", "A1", &header); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.MergeCell("Sheet1", "L1", "M1"); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "merged.xlsx")
+	if err := f.SaveAs(path); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readHeader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 13 || got[12] != "Weeks" {
+		t.Errorf("header = %q, want Weeks across L and M", got)
+	}
+}
+
+func TestODSMergedHeader(t *testing.T) {
+	resetState(t)
+	const content = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+  xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+  xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0">
+<office:body><office:spreadsheet><table:table table:name="Sheet1">
+<table:table-row>
+  <table:table-cell><text:p>Emplid</text:p></table:table-cell>
+  <table:table-cell table:number-columns-spanned="2"><text:p>Quiz</text:p></table:table-cell>
+  <table:covered-table-cell/>
+  <table:table-cell><text:p>Total</text:p></table:table-cell>
+</table:table-row>
+</table:table></office:spreadsheet></office:body></office:document-content>`
+	path := filepath.Join(t.TempDir(), "merged.ods")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := zip.NewWriter(out)
+	w, err := z.Create("content.xml")
+	if err == nil {
+		_, err = w.Write([]byte(content))
+	}
+	if err == nil {
+		err = z.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readHeader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Emplid", "Quiz", "Quiz", "Total"}; !slices.Equal(got, want) {
+		t.Errorf("header = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Minimum level of log records written, set by --log-level
+var logLevel = new(slog.LevelVar)
+
+// Installs the log handler for --log-format and --log-level. "plain" keeps the standard
+// log layout; "text" and "json" write structured key=value or JSON records to stderr,
+// including the lines written through the log package.
+func setupLogging(format, level string) error {
+	if err := logLevel.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid --log-level %q: must be \"debug\", \"info\", \"warn\" or \"error\"", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: logLevel}
+	switch strings.ToLower(format) {
+	case "plain":
+		slog.SetLogLoggerLevel(logLevel.Level())
+	case "text":
+		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, opts)))
+	case "json":
+		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, opts)))
+	default:
+		return fmt.Errorf("invalid --log-format %q: must be \"plain\", \"text\" or \"json\"", format)
+	}
+	return nil
+}
+
+// Returns the log level of a finding level
+func slogLevel(level string) slog.Level {
+	switch level {
+	case levelError:
+		return slog.LevelError
+	case levelWarning:
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
+}
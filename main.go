@@ -1,116 +1,1835 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"maps"
 	"math"
 	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"slices"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/xuri/excelize/v2"
 )
 
 // Student structure
 type Student struct {
-	EmpID      string
-	Branch     string
-	Quiz       float64
-	MidSem     float64
-	LabTest    float64
-	WeeklyLabs float64
-	Compre     float64
-	Total      float64
+	EmpID      string  `json:"empID"`
+	Branch     string  `json:"branch"`
+	Quiz       float64 `json:"quiz"`
+	MidSem     float64 `json:"midSem"`
+	LabTest    float64 `json:"labTest"`
+	WeeklyLabs float64 `json:"weeklyLabs"`
+	Compre     float64 `json:"compre"`
+	Total      float64 `json:"total"`
+
+	CompreAttempt int       `json:"compreAttempt,omitempty"` // 1-based attempt used when several compre columns are configured
+	Weeks         []float64 `json:"weeks,omitempty"`         // individual weekly lab scores when --weekly-columns is set
+	Row           int       `json:"-"`                       // 1-based sheet row the student was read from
+	Blank         []string  `json:"blank,omitempty"`         // keys of components left blank, as opposed to a mark of zero
+}
+
+// Aggregated data collected while processing a file
+type Results struct {
+	Students     []Student
+	BranchSums   map[string]float64
+	BranchCounts map[string]int
+	BranchPasses map[string]int
+	TotalSum     float64
+	TotalCount   int
+	TotalPasses  int
+
+	SkippedShort     int      // rows with too few columns
+	SkippedBranch    int      // rows with an unrecognized branch
+	SkippedMalformed int      // rows with non-numeric mark cells, under --skip-malformed
+	SkippedSummary   int      // summary rows such as "Average" (not counted by Skipped)
+	SkippedHeaders   int      // header rows repeated inside the data (not counted by Skipped)
+	NoComponents     []string // EmpIDs with a Total but no component marks
+	MissingCompre    []string // EmpIDs with a blank or zero compre under --compre-required
+
+	MissingRequired map[string][]string // EmpIDs missing each --required component, keyed by component key
+	SkippedRows     []SkippedRow
+	Filtered        int // valid rows excluded by --where
+
+	ComponentStats map[string]componentStat // mean and standard deviation per component key
+}
+
+// Copies aggregated statistics into the results
+func (r *Results) setAggregate(agg Aggregate) {
+	r.BranchSums = agg.BranchSums
+	r.BranchCounts = agg.BranchCounts
+	r.BranchPasses = agg.BranchPasses
+	r.TotalSum = agg.TotalSum
+	r.TotalCount = agg.TotalCount
+	r.TotalPasses = agg.TotalPasses
+	r.ComponentStats = agg.ComponentStats
+}
+
+// A data row that was not parsed as a student
+type SkippedRow struct {
+	Row    int // 1-based row number in the sheet
+	Reason string
+	Cells  []string
+}
+
+// Reasons recorded for skipped rows
+const (
+	skipShortRow      = "too few columns"
+	skipInvalidBranch = "invalid branch"
+	skipMalformed     = "malformed mark"
+	skipSummaryRow    = "summary row"
+	skipHeaderRow     = "repeated header"
+)
+
+// Returns the number of non-empty data rows that were skipped
+func (r Results) Skipped() int {
+	return r.SkippedShort + r.SkippedBranch + r.SkippedMalformed
+}
+
+// Returns the percentage of non-empty data rows that were skipped
+func (r Results) SkippedPercent() float64 {
+	rows := r.TotalCount + r.Skipped()
+	if rows == 0 {
+		return 0
+	}
+	return 100 * float64(r.Skipped()) / float64(rows)
+}
+
+// A reportable mark component and how to read it from a student
+type component struct {
+	key    string
+	label  string
+	max    float64
+	getVal func(Student) float64
+}
+
+// Components reported in the top lists, in display order. Their maxima are the single
+// source for validation, normalization and display labels, and --component-max overrides them.
+var components = []component{
+	{"quiz", "Quiz", 30, func(s Student) float64 { return s.Quiz }},
+	{"midsem", "Mid-Sem", 75, func(s Student) float64 { return s.MidSem }},
+	{"labtest", "Lab Test", 60, func(s Student) float64 { return s.LabTest }},
+	{"weekly", "Weekly Labs", 30, func(s Student) float64 { return s.WeeklyLabs }},
+	{"compre", "Compre", 105, func(s Student) float64 { return s.Compre }},
+	{"total", "Total", 300, func(s Student) float64 { return s.Total }},
+}
+
+// Returns the display name of the component, including its maximum, e.g. "Mid-Sem (75)"
+func (c component) name() string {
+	return fmt.Sprintf("%s (%g)", c.label, c.max)
+}
+
+// Replaces the components in a KEY,KEY:Label spec with one component that sums or
+// averages them, placed where the first of them was. Its key is the lowercased label.
+func mergeComponents(spec, rule string) error {
+	rawKeys, label, ok := strings.Cut(spec, ":")
+	label = strings.TrimSpace(label)
+	if !ok || label == "" {
+		return fmt.Errorf("expected KEY,KEY:Label, got %q", spec)
+	}
+	sources, err := parseComponentList(rawKeys)
+	if err != nil {
+		return err
+	}
+	if len(sources) < 2 {
+		return fmt.Errorf("at least two components are needed to merge")
+	}
+
+	merged := component{
+		key:   strings.ToLower(strings.ReplaceAll(label, " ", "")),
+		label: label,
+		getVal: func(s Student) float64 {
+			var sum float64
+			for _, comp := range sources {
+				sum += comp.getVal(s)
+			}
+			if rule == "average" {
+				return sum / float64(len(sources))
+			}
+			return sum
+		},
+	}
+	for _, comp := range sources {
+		if comp.key == "total" {
+			return fmt.Errorf("total cannot be merged")
+		}
+		merged.max += comp.max
+	}
+	if rule == "average" {
+		merged.max /= float64(len(sources))
+	}
+
+	var result []component
+	for _, comp := range components {
+		isSource := false
+		for _, src := range sources {
+			isSource = isSource || src.key == comp.key
+		}
+		switch {
+		case !isSource:
+			result = append(result, comp)
+		case comp.key == sources[0].key:
+			result = append(result, merged)
+		}
+	}
+	components = result
+	return nil
+}
+
+// Components in the order their top lists are reported, from --components-order
+var reportComponents = components
+
+// Returns the components named in a comma-separated order spec, followed by the unlisted
+// components in their usual order unless strict is set
+func orderComponents(spec string, strict bool) ([]component, error) {
+	ordered, err := parseComponentList(spec)
+	if err != nil {
+		return nil, err
+	}
+	listed := make(map[string]bool)
+	for _, comp := range ordered {
+		if listed[comp.key] {
+			return nil, fmt.Errorf("component %q listed twice", comp.key)
+		}
+		listed[comp.key] = true
+	}
+	if !strict {
+		for _, comp := range components {
+			if !listed[comp.key] {
+				ordered = append(ordered, comp)
+			}
+		}
+	}
+	return ordered, nil
+}
+
+// Overrides component maxima from a key=max map, as given to --component-max
+func setComponentMaxima(maxima map[string]float64) error {
+	for key, value := range maxima {
+		found := false
+		for i := range components {
+			if components[i].key == key {
+				components[i].max = value
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown component %q (valid components: %s)", key, strings.Join(componentKeys(), ", "))
+		}
+		if value <= 0 {
+			return fmt.Errorf("maximum for %s must be positive", key)
+		}
+	}
+	return nil
+}
+
+// Sets the Total maximum to a number, or to the sum of the other components' maxima for "sum"
+func setMaxTotal(spec string) error {
+	value := 0.0
+	if spec == "sum" {
+		for _, comp := range components {
+			if comp.key != "total" {
+				value += comp.max
+			}
+		}
+	} else {
+		parsed, err := strconv.ParseFloat(spec, 64)
+		if err != nil {
+			return fmt.Errorf("expected a number or \"sum\", got %q", spec)
+		}
+		value = parsed
+	}
+	return setComponentMaxima(map[string]float64{"total": value})
+}
+
+// Component weights from --weights, nil when not set
+var componentWeights map[string]float64
+
+// Sets the weights applied to each component's marks, checking every key names a component
+func setComponentWeights(weights map[string]float64) error {
+	for key, value := range weights {
+		if _, ok := componentByKey(key); !ok {
+			return fmt.Errorf("unknown component %q (valid components: %s)", key, strings.Join(componentKeys(), ", "))
+		}
+		if key == "total" {
+			return fmt.Errorf("total cannot be weighted; weight the components that make it up")
+		}
+		if value < 0 {
+			return fmt.Errorf("weight for %s must not be negative", key)
+		}
+	}
+	componentWeights = weights
+	return nil
+}
+
+// Returns the sum of a student's component marks scaled by --weights, weight 1 by default
+func weightedTotal(s Student) float64 {
+	var sum float64
+	for _, comp := range components {
+		if comp.key == "total" {
+			continue
+		}
+		weight, ok := componentWeights[comp.key]
+		if !ok {
+			weight = 1
+		}
+		sum += weight * comp.getVal(s)
+	}
+	return sum
+}
+
+// Replaces component display labels from a key=label list, as given to --component-alias,
+// leaving the keys and column mapping unchanged
+func setComponentLabels(spec string) error {
+	for _, pair := range strings.Split(spec, ",") {
+		key, label, ok := strings.Cut(pair, "=")
+		key, label = strings.TrimSpace(key), strings.TrimSpace(label)
+		if !ok || label == "" {
+			return fmt.Errorf("invalid entry %q: expected key=label", pair)
+		}
+		found := false
+		for i := range components {
+			if components[i].key == key {
+				components[i].label = label
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown component %q (valid components: %s)", key, strings.Join(componentKeys(), ", "))
+		}
+	}
+	return nil
+}
+
+// Resolves a comma-separated list of component keys
+func parseComponentList(spec string) ([]component, error) {
+	var selected []component
+	for _, key := range strings.Split(spec, ",") {
+		comp, ok := componentByKey(strings.TrimSpace(key))
+		if !ok {
+			return nil, fmt.Errorf("unknown component %q (valid components: %s)", key, strings.Join(componentKeys(), ", "))
+		}
+		selected = append(selected, comp)
+	}
+	return selected, nil
+}
+
+// Returns the keys of all components in display order
+func componentKeys() []string {
+	keys := make([]string, len(components))
+	for i, comp := range components {
+		keys[i] = comp.key
+	}
+	return keys
+}
+
+// Returns the component with the given key
+func componentByKey(key string) (component, bool) {
+	for _, comp := range components {
+		if comp.key == key {
+			return comp, true
+		}
+	}
+	return component{}, false
+}
+
+// Branch name mapping
+var branchMap = map[string]string{
+	"2021A2": "Civil 2021", "2024A3": "EEE 2024", "2024A4": "Mechanical 2024",
+	"2024A5": "Pharma 2024", "2024A7": "CSE 2024", "2024A8": "ENI 2024", "2024AA": "ECE 2024",
+	"2024AD": "MnC 2024", "2024B1": "MSc Biology", "2020B5": "MSc Physics 2020", "2021A7": "CSE 2021", "2022A7": "CSE 2022",
+	"2023A7": "CSE 2023", "2021A8": "ENI 2021", "2021AA": "ECE 2021", "2021B1": "Msc Biology 2021", "2021B4": "Msc Maths 2021",
+	"2021B5": "Msc Physics 2021", "2022A1": "Chemical 2022", "2022A2": "Civil 2022", "2022A3": "EEE 2022", "2022A4": "Mechanical 2022",
+	"2022AA": "ECE 2022", "2022B2": "MSc Chemistry 2022", "2023A5": "Pharma 2023", "2023A8": "ENI 2023",
+}
+
+// Label for the student identifier in output, replaced by the header of --id-column
+var idLabel = "EmpID"
+
+// Branch codes listed in the workbook's Branches sheet, nil when the sheet is absent
+var sheetBranches map[string]bool
+
+const branchSheetName = "Branches" // optional sheet mapping branch codes to names
+
+const defaultTolerance = 0.01 // handling floating point precision
+
+// Command line flags
+var (
+	fixTotalsFlag            = flag.Bool("fix-totals", false, "write computed totals into discrepant Total cells and save a corrected copy")
+	fixOutFlag               = flag.String("fix-out", "", "output path for --fix-totals (default <input>-fixed.xlsx)")
+	decimalSep               = flag.String("decimal-sep", ".", "decimal separator used in numeric cells (\".\" or \",\")")
+	suggestFlag              = flag.Int("suggest-cutoffs", 0, "suggest total-score cutoffs for the given number of grade bands")
+	cutoffMethod             = flag.String("cutoff-method", "gaps", "cutoff suggestion method: \"gaps\" or \"quantile\"")
+	countOnlyFlag            = flag.Bool("count-only", false, "print only the number of valid student rows")
+	passFlag                 = flag.Float64("pass", 0, "total marks required to pass (0 disables pass rates)")
+	formatFlag               = flag.String("format", "text", "output format: \"text\", \"json\", \"json-full\" (summary plus every student), \"md\" (Markdown tables) or \"csv\" (the json summary as rows)")
+	jsonSchemaFlag           = flag.Bool("json-schema", false, "print the JSON Schema of the --format json output and exit")
+	noHeaderFlag             = flag.Bool("no-header", false, "treat the first row as data instead of a header")
+	noColorFlag              = flag.Bool("no-color", false, "disable ANSI colors in terminal output")
+	maxSkippedFlag           = flag.Float64("max-skipped", 100, "abort when more than this percentage of data rows is skipped")
+	neededForFlag            = flag.String("needed-for", "", "compute the compre marks a student needs for a target total, as EMPID:TARGET")
+	dbFlag                   = flag.String("db", "", "append per-student records to this SQLite database")
+	runLabelFlag             = flag.String("run-label", "", "label identifying this run in --db (default input file name)")
+	branchPassFlag           = flag.String("branch-pass", "", "per-branch pass thresholds overriding --pass, e.g. 2024A7=160,2024A3=150")
+	fieldsFlag               = flag.String("fields", "", "comma-separated columns to include in --dump-out, in order (default all); component columns are named after their --component-alias labels")
+	grossFlag                = flag.Float64("gross-threshold", 20, "marks by which a row's discrepancy must exceed the cohort's typical offset to be flagged as a formula or paste error")
+	serveFlag                = flag.String("serve", "", "serve the results as a JSON API on this address, e.g. :8080")
+	baselineFlag             = flag.String("baseline", "", "compare component averages against a baseline JSON file")
+	saveBaseFlag             = flag.String("save-baseline", "", "write this run's component averages to a baseline JSON file")
+	timeoutFlag              = flag.Duration("timeout", defaultFetchTimeout, "timeout for downloading a workbook given as an http(s) URL")
+	rankFlag                 = flag.Bool("rank-branches", false, "print branches ranked by total marks")
+	branchMetric             = flag.String("branch-metric", "mean", "metric used to rank branches: \"mean\" or \"median\"")
+	minBranchSize            = flag.Int("min-branch-size", 1, "branches with fewer students are listed but excluded from the ranking")
+	skippedOutFlag           = flag.String("emit-skipped-rows", "", "write every skipped row with its row number and reason to this CSV file")
+	tiebreakFlag             = flag.String("award-tiebreak", "", "components used in order to break ties in the Total top list, e.g. compre,midsem")
+	minValidColsFlag         = flag.Int("min-valid-columns", 0, "minimum cells a row needs to be parsed (default derived from the column layout)")
+	weakestFlag              = flag.Bool("weakest", false, "report each branch's weakest component relative to its maximum")
+	webhookFlag              = flag.String("webhook", "", "POST the JSON summary to this URL when processing completes")
+	webhookOnFlag            = flag.String("webhook-on", "always", "when to call --webhook: \"always\" or \"error\"")
+	rangeFlag                = flag.String("range", "", "restrict parsing to a cell range such as A1:K200; the first row of the range is the header")
+	reportHashFlag           = flag.Bool("report-hash", false, "print a short hash of the parsed data and key statistics")
+	whereFlag                = flag.String("where", "", "only include students matching an expression, e.g. \"total>250 and branch=2024A7\"")
+	normalizeIDFlag          = flag.Bool("normalize-empid", false, "uppercase EmpIDs and collapse stray whitespace before use")
+	simulateFlag             = flag.String("simulate-cutoff", "", "simulate grade cutoffs such as A:260,B:220 and report the distribution")
+	boundaryFlag             = flag.Float64("boundary-margin", 2, "marks from a cutoff within which --simulate-cutoff lists students")
+	quizColumnsFlag          = flag.String("quiz-columns", "", "column letters of individual quizzes aggregated into Quiz, e.g. L,M,N")
+	quizBestOfFlag           = flag.Int("quiz-best-of", 0, "number of best quizzes from --quiz-columns counted toward Quiz (default all)")
+	quizAggFlag              = flag.String("quiz-aggregate", "sum", "how the best quizzes combine into Quiz: \"sum\" or \"average\"")
+	skipLabelsFlag           = flag.String("skip-labels", "Average,Max,Min,Total", "labels that mark summary rows to skip when found in a leading cell")
+	gradesFlag               = flag.String("grades", "", "grade cutoffs on Total such as A:260,A-:240,B:220 used to assign grades")
+	toleranceFlag            = flag.Float64("tolerance", defaultTolerance, "absolute tolerance when comparing totals")
+	relToleranceFlag         = flag.Float64("rel-tolerance", 0, "relative tolerance when comparing totals, as a fraction of the larger magnitude")
+	toleranceMode            = flag.String("tolerance-mode", "abs", "which tolerance applies: \"abs\", \"rel\", \"either\" (one suffices) or \"both\"")
+	compreColumnsFlag        = flag.String("compre-columns", "", "column letters of compre attempts in sitting order, e.g. J,L")
+	compreRuleFlag           = flag.String("compre-rule", "max", "which compre attempt counts: \"max\" or \"latest\"")
+	explainFlag              = flag.String("explain", "", "print the full mark breakdown for one EmpID")
+	auditLogFlag             = flag.String("audit-log", "", "write every skip, exclusion and discrepancy decision to this JSONL file")
+	idColumnFlag             = flag.Int("id-column", -1, "zero-based column holding the student identifier (default 2, the EmpID column); its header label is used in output")
+	statsFlag                = flag.Bool("stats", false, "print distribution statistics of totals overall and per branch")
+	watchDirFlag             = flag.String("watch-dir", "", "watch this directory and process every new .xlsx dropped into it")
+	watchOutFlag             = flag.String("watch-out", "", "directory for --watch-dir reports (default <watch-dir>/reports)")
+	zRankFlag                = flag.Int("z-ranking", 0, "print the top N students by composite z-score across components (0 disables)")
+	topOverallFlag           = flag.Bool("top-overall", false, "print a consolidated leaderboard of the best students by Total with branch, rank and grade")
+	topFlag                  = flag.Int("top", 3, "number of students listed per component, in the --top-overall leaderboard and per component in --group-report")
+	allowCurveFlag           = flag.Bool("allow-curve", false, "treat component values above their maximum as informational notices instead of errors")
+	summaryJSONFlag          = flag.String("summary-json", "", "also write the JSON summary to this file while printing the text report")
+	colOffsetFlag            = flag.Int("col-offset", -1, "shift every column right by N, e.g. 1 for a leading index column (default auto-detect)")
+	appealFlag               = flag.Float64("appeal-margin", 0, "list students within this many marks below a --grades cutoff as likely appeals (0 disables)")
+	componentMaxFlag         = flag.String("component-max", "", "override component maxima as key=max pairs, e.g. midsem=90,total=315")
+	sampleFlag               = flag.Int("sample", 0, "print the full breakdown of N randomly chosen students for spot checks")
+	seedFlag                 = flag.Uint64("seed", 0, "random seed for --sample; runs with the same seed pick the same students (default random, printed)")
+	perBranchDirFlag         = flag.String("per-branch-dir", "", "write <branch>.csv for each branch into this directory, ranked by Total")
+	minCountFlag             = flag.Int("min-count", 0, "exclude branches with fewer than N students from all reports and exports")
+	trimFlag                 = flag.Float64("trim", 0, "also report averages with the top and bottom PERCENT of totals discarded")
+	templateFlag             = flag.String("template", "", "write an empty grade-entry workbook in the expected layout to this path and exit")
+	jsonPrettyFlag           = flag.Bool("json-pretty", false, "indent JSON output and sidecar files with two spaces")
+	consistencyTotalFlag     = flag.Float64("consistency-total", 240, "Total at or above which a zero --consistency-components value is flagged as inconsistent")
+	consistencyCompsFlag     = flag.String("consistency-components", "compre,midsem", "components that cannot be zero or blank for a high Total (empty disables the check)")
+	groupReportFlag          = flag.Bool("group-report", false, "print the top students in each component within each branch")
+	passwordFlag             = flag.String("password", "", "password for an encrypted workbook (or set GRADES_XLSX_PASSWORD to keep it out of shell history)")
+	mergeFlag                = flag.String("merge-components", "", "report several components as one, as KEY,KEY:Label, e.g. labtest,weekly:Lab")
+	mergeRuleFlag            = flag.String("merge-rule", "sum", "how --merge-components combines values: \"sum\" or \"average\"")
+	declineFlag              = flag.Float64("decliners", 0, "list students whose mid-sem percentage fell more than this many points below their quiz percentage (0 disables)")
+	dryRunFlag               = flag.Bool("dry-run", false, "list the artifacts the run would write, including --fix-totals output, without writing anything")
+	branchFromEmpIDFlag      = flag.Bool("branch-from-empid", false, "take the branch from the EmpID prefix when the campus ID has none")
+	componentsOrderFlag      = flag.String("components-order", "", "order of the per-component top lists, e.g. total,compre,midsem")
+	strictOrderFlag          = flag.Bool("strict-order", false, "omit components not named in --components-order instead of appending them")
+	requireCompsFlag         = flag.Bool("require-components", false, "drop students whose Total has no component marks behind it")
+	gradePointsFlag          = flag.String("grade-points", "", "grade point for each --grades grade, e.g. A=10,B=8,C=6, reported as branch averages")
+	maxMemoryFlag            = flag.Int("max-memory", 0, "best-effort memory limit in MB: the garbage collector is asked to stay under it, and a run still above it when checked every 5000 rows aborts cleanly; memory can exceed it between checks (0 disables)")
+	explainDiscFlag          = flag.Bool("explain-discrepancies", false, "group total discrepancies by the size of the delta to spot systematic causes")
+	topPercentFlag           = flag.Float64("top-percent", 0, "list the top PERCENT of students per component instead of the top --top, rounded up to at least one")
+	reportCardsFlag          = flag.String("report-cards", "", "write one report card per student into this directory")
+	cardFormatFlag           = flag.String("report-card-format", "html", "format of --report-cards: \"html\" or \"txt\"")
+	compreRequiredFlag       = flag.Bool("compre-required", false, "flag students with a blank or zero compre score, for runs after the compre")
+	compreStrictFlag         = flag.Bool("compre-strict", false, "with --compre-required, drop students missing a compre score")
+	keepGoingFlag            = flag.Bool("keep-going", false, "process every file argument in turn like a single file, logging failures instead of stopping, and print which files succeeded; each file's exports get its name, e.g. dump-<file>.csv")
+	weightsFlag              = flag.String("weights", "", "rank the top Total list by component marks scaled by key=weight pairs, e.g. compre=1.5,quiz=0.5")
+	schemaFlag               = flag.String("validate-schema", "", "fail unless the header row matches this manifest of column headers, one per line with a blank line for a blank cell, exactly")
+	compareFlag              = flag.String("compare", "", "report how each student's overall rank changed since this earlier workbook")
+	strictColumnsFlag        = flag.Bool("strict-columns", false, "fail when the sheet has columns beyond those the column layout reads")
+	branchSummaryFlag        = flag.Bool("branch-summary-only", false, "print only the ranked branch table with counts, averages and pass rates")
+	branchSummaryOutFlag     = flag.String("branch-summary-out", "", "with --branch-summary-only, write the table to this .csv or .html file instead")
+	tzFlag                   = flag.String("tz", "", "time zone of report timestamps, e.g. Asia/Kolkata (default local time)")
+	hashTimestampFlag        = flag.Bool("hash-timestamp", false, "include the generation timestamp in the report hash and checksum")
+	combinedFlag             = flag.String("combined-report", "", "process every sheet separately and write a workbook of per-sheet and aggregate statistics to this path")
+	componentAliasFlag       = flag.String("component-alias", "", "display labels for components as key=label pairs, e.g. quiz=Assignments")
+	preferComputedFlag       = flag.Bool("prefer-computed", false, "use component sums instead of the Total column when too many totals disagree with them")
+	preferComputedThreshold  = flag.Float64("prefer-computed-threshold", 0.2, "fraction of students with a discrepancy above which --prefer-computed switches to computed totals")
+	matrixFlag               = flag.String("matrix", "", "write a CSV matrix of branch, ID and every component per student to this path")
+	normalizedFlag           = flag.Bool("normalized", false, "write --matrix marks as percentages of each component's maximum")
+	historyFlag              = flag.String("history", "", "print this EmpID's marks across the runs stored in --db and exit")
+	sinceFlag                = flag.String("since", "", "with --history, only include runs stored on or after this date (YYYY-MM-DD)")
+	branchCreditsFlag        = flag.String("branch-credits", "", "credits per branch as code=credits pairs; adds an overall average weighting each branch by its credits")
+	componentPassFlag        = flag.String("component-pass", "", "minimum marks per component as key=min pairs, e.g. compre=35; lists students below one despite a passing Total")
+	weeklyColumnsFlag        = flag.String("weekly-columns", "", "column letters of individual weekly labs summed into Weekly Labs, e.g. L,M,N")
+	flattenWeeklyFlag        = flag.Bool("flatten-weekly", false, "add a week_N column per --weekly-columns lab to the dump and per-branch files")
+	maxTotalFlag             = flag.String("max-total", "", "maximum Total used in labels and range checks: a number, or \"sum\" for the sum of the component maxima")
+	logFormatFlag            = flag.String("log-format", "plain", "log output: \"plain\", or structured \"text\" (key=value) or \"json\" records")
+	logLevelFlag             = flag.String("log-level", "info", "minimum log level: \"debug\" (includes audit decisions), \"info\", \"warn\" or \"error\"")
+	requiredFlag             = flag.String("required", "", "components that must be non-blank, e.g. quiz,midsem; students missing one are reported")
+	requiredStrictFlag       = flag.Bool("required-strict", false, "with --required, drop students missing a required component")
+	dumpDiscFlag             = flag.String("dump-discrepancies", "", "write only the students whose Total disagrees with the component sum to this CSV file")
+	normalizeBranchNamesFlag = flag.Bool("normalize-branch-names", false, "normalize branch display names to title case with a consistent \"MSc\" prefix")
+	contributionFlag         = flag.Bool("branch-contribution", false, "report how far each branch pulls the overall average up or down, weighted by its share of students")
+	sheetAutoFlag            = flag.Bool("sheet-auto", false, "read the sheet whose header best matches the expected columns instead of the first sheet")
+	branchPrefixLenFlag      = flag.Int("branch-prefix-len", 6, "number of leading campus ID characters that form the branch code")
+	failOnDiscrepancyFlag    = flag.Int("fail-on-discrepancy", -1, "exit non-zero when more than N rows have a total discrepancy beyond the tolerance; negative disables")
+	componentRanksFlag       = flag.Bool("component-ranks", false, "add a <component>_rank column per component to --dump-out and --per-branch-dir, ranked across the cohort")
+	profileFlag              = flag.String("profile", "", "write a CPU profile of the processing pipeline to this file")
+	memProfileFlag           = flag.String("memprofile", "", "write a heap profile to this file when processing finishes")
+	chartFlag                = flag.String("chart", "", "write a stacked bar chart of each branch's --grades distribution to this .svg or .html file")
+	targetPassRateFlag       = flag.Float64("target-pass-rate", 0, "find the compre weight or offset giving this pass rate, as a fraction such as 0.7, and exit")
+	targetByFlag             = flag.String("target-by", "weight", "what --target-pass-rate varies: \"weight\" (scale compre) or \"offset\" (add a flat curve to compre)")
+	mergeYearsFlag           = flag.Bool("merge-years", false, "also report branch averages with the admission year stripped from branch codes, so each program aggregates across years")
+	dumpOutFlag              = flag.String("dump-out", "", "write every valid student to this file, in --dump-format or the format its extension names")
+	dumpFormatFlag           = flag.String("dump-format", "", "serialization of --dump-out: csv, json, jsonl, tsv or parquet (default from the extension)")
+	outFlag                  = flag.String("out", "", "write the report to this file instead of stdout")
+	skipMalformedFlag        = flag.Bool("skip-malformed", false, "skip rows with non-numeric text in a mark cell instead of reading it as 0")
+	detectColumnsFlag        = flag.Bool("detect-columns", false, "locate each column by its header label, such as \"Quiz\" or \"Campus ID\", instead of by position")
+	componentsFlag           = flag.String("components", "", "report only these components in the per-component top lists, e.g. compre or quiz,total")
+)
+
+// Components that must not be zero for a high Total, from --consistency-components
+var majorComponents []component
+
+// Alias definitions from --branch-alias, which may be repeated
+var branchAliasFlag multiFlag
+
+func init() {
+	flag.Var(&branchAliasFlag, "branch-alias", "aggregate several branch codes under one name, e.g. CSE=2021A7,2022A7 (repeatable)")
+}
+
+// Per-branch pass thresholds parsed from --branch-pass
+var branchPassThresholds map[string]float64
+
+// Components parsed from --award-tiebreak
+var awardTiebreak []component
+
+// Grade cutoffs parsed from --grades, highest first
+var gradeCutoffs []gradeCutoff
+
+func main() {
+	flag.Usage = func() {
+		fmt.Println("Usage - go run main.go [flags] <path-to-file.xlsx>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	setupColor(*noColorFlag, *outFlag != "")
+	if *maxMemoryFlag > 0 {
+		debug.SetMemoryLimit(int64(*maxMemoryFlag) << 20)
+	}
+
+	if err := setupLogging(*logFormatFlag, *logLevelFlag); err != nil {
+		log.Fatal(err)
+	}
+	// Writes a report to stdout or --out, leaving any earlier --out file in place on error
+	report := func(print func(w io.Writer) error) error {
+		return writeReport(*outFlag, print)
+	}
+
+	if *tzFlag != "" {
+		loc, err := time.LoadLocation(*tzFlag)
+		if err != nil {
+			log.Fatalf("Invalid --tz %q: %v", *tzFlag, err)
+		}
+		reportLocation = loc
+	}
+
+	if *jsonSchemaFlag {
+		if err := report(func(w io.Writer) error { return printJSONSchema(w, Summary{}) }); err != nil {
+			log.Fatalf("Failed to write the JSON schema: %v", err)
+		}
+		return
+	}
+
+	if *sinceFlag != "" && *historyFlag == "" {
+		log.Fatalf("--since requires --history")
+	}
+	if *historyFlag != "" {
+		if *dbFlag == "" {
+			log.Fatalf("--history requires --db")
+		}
+		var since time.Time
+		if *sinceFlag != "" {
+			parsed, err := time.ParseInLocation(time.DateOnly, *sinceFlag, reportLocation)
+			if err != nil {
+				log.Fatalf("Invalid --since %q: expected YYYY-MM-DD", *sinceFlag)
+			}
+			since = parsed
+		}
+		entries, err := loadHistory(*dbFlag, *historyFlag, since)
+		if err != nil {
+			log.Fatalf("Failed to read history from %s: %v", *dbFlag, err)
+		}
+		err = report(func(w io.Writer) error {
+			printHistory(w, *historyFlag, entries)
+			return nil
+		})
+		if err != nil {
+			log.Fatalf("Failed to write %s: %v", *outFlag, err)
+		}
+		return
+	}
+
+	if flag.NArg() < 1 && *watchDirFlag == "" && *templateFlag == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+	switch *formatFlag {
+	case "text", "json", "json-full", "md", "csv":
+	default:
+		log.Fatalf("Invalid --format %q: must be \"text\", \"json\", \"json-full\", \"md\" or \"csv\"", *formatFlag)
+	}
+	switch *toleranceMode {
+	case "abs", "rel", "either", "both":
+	default:
+		log.Fatalf("Invalid --tolerance-mode %q: must be \"abs\", \"rel\", \"either\" or \"both\"", *toleranceMode)
+	}
+	if *webhookOnFlag != "always" && *webhookOnFlag != "error" {
+		log.Fatalf("Invalid --webhook-on %q: must be \"always\" or \"error\"", *webhookOnFlag)
+	}
+	if *branchMetric != "mean" && *branchMetric != "median" {
+		log.Fatalf("Invalid --branch-metric %q: must be \"mean\" or \"median\"", *branchMetric)
+	}
+	if *topPercentFlag < 0 || *topPercentFlag > 100 {
+		log.Fatalf("Invalid --top-percent %g: must be between 0 and 100", *topPercentFlag)
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "top" && *topPercentFlag > 0 {
+			log.Fatalf("--top and --top-percent cannot be used together")
+		}
+	})
+	if *trimFlag < 0 || *trimFlag >= 50 {
+		log.Fatalf("Invalid --trim %g: must be at least 0 and below 50", *trimFlag)
+	}
+	if *topFlag < 1 {
+		log.Fatalf("Invalid --top %d: must be at least 1", *topFlag)
+	}
+	if *decimalSep != "." && *decimalSep != "," {
+		log.Fatalf("Invalid --decimal-sep %q: must be \".\" or \",\"", *decimalSep)
+	}
+
+	componentMax := parseFloatMap(*componentMaxFlag, "--component-max")
+	if err := setComponentMaxima(componentMax); err != nil {
+		log.Fatalf("Invalid --component-max: %v", err)
+	}
+	if _, ok := componentMax["total"]; ok && *maxTotalFlag != "" {
+		log.Fatalf("--max-total and --component-max total=... cannot be used together")
+	}
+	if *maxTotalFlag != "" {
+		if err := setMaxTotal(*maxTotalFlag); err != nil {
+			log.Fatalf("Invalid --max-total: %v", err)
+		}
+	}
+	if *componentAliasFlag != "" {
+		if err := setComponentLabels(*componentAliasFlag); err != nil {
+			log.Fatalf("Invalid --component-alias: %v", err)
+		}
+	}
+	if *templateFlag != "" && *dryRunFlag {
+		err := report(func(w io.Writer) error {
+			return printArtifacts(w, []artifact{{*templateFlag, "xlsx template", templateRows + 1}})
+		})
+		if err != nil {
+			log.Fatalf("Failed to write %s: %v", *outFlag, err)
+		}
+		return
+	}
+	if *templateFlag != "" {
+		if err := writeTemplate(*templateFlag); err != nil {
+			log.Fatalf("Failed to write template %s: %v", *templateFlag, err)
+		}
+		fmt.Printf("Template written to %s\n", *templateFlag)
+		return
+	}
+	if *mergeRuleFlag != "sum" && *mergeRuleFlag != "average" {
+		log.Fatalf("Invalid --merge-rule %q: must be \"sum\" or \"average\"", *mergeRuleFlag)
+	}
+	if *mergeFlag != "" {
+		if err := mergeComponents(*mergeFlag, *mergeRuleFlag); err != nil {
+			log.Fatalf("Invalid --merge-components: %v", err)
+		}
+	}
+	if *requiredFlag != "" {
+		required, err := parseComponentList(*requiredFlag)
+		if err != nil {
+			log.Fatalf("Invalid --required: %v", err)
+		}
+		for _, comp := range required {
+			if componentColumns(comp.key) == nil {
+				log.Fatalf("Invalid --required: %s is a merged component; require its parts instead", comp.key)
+			}
+		}
+		requiredComponents = required
+	}
+	if *requiredStrictFlag && *requiredFlag == "" {
+		log.Fatalf("--required-strict requires --required")
+	}
+	if *componentPassFlag != "" {
+		componentPassMarks = parseFloatMap(*componentPassFlag, "--component-pass")
+		for key := range componentPassMarks {
+			if _, ok := componentByKey(key); !ok {
+				log.Fatalf("Invalid --component-pass: unknown component %q (valid components: %s)", key, strings.Join(componentKeys(), ", "))
+			}
+		}
+	}
+	if *branchCreditsFlag != "" {
+		branchCredits = parseFloatMap(*branchCreditsFlag, "--branch-credits")
+		for branch, credit := range branchCredits {
+			if credit <= 0 {
+				log.Fatalf("Invalid --branch-credits: credits for %s must be positive", branch)
+			}
+		}
+	}
+	if *weightsFlag != "" {
+		if err := setComponentWeights(parseFloatMap(*weightsFlag, "--weights")); err != nil {
+			log.Fatalf("Invalid --weights: %v", err)
+		}
+	}
+	reportComponents = components
+	if *componentsOrderFlag != "" {
+		ordered, err := orderComponents(*componentsOrderFlag, *strictOrderFlag)
+		if err != nil {
+			log.Fatalf("Invalid --components-order: %v", err)
+		}
+		reportComponents = ordered
+	}
+	if *componentsFlag != "" {
+		if *componentsOrderFlag != "" {
+			log.Fatalf("--components and --components-order cannot be used together")
+		}
+		selected, err := orderComponents(*componentsFlag, true)
+		if err != nil {
+			log.Fatalf("Invalid --components: %v", err)
+		}
+		reportComponents = selected
+	}
+
+	if *normalizeBranchNamesFlag {
+		for code, name := range branchMap {
+			branchMap[code] = normalizeBranchName(name)
+		}
+	}
+
+	branchPassThresholds = parseFloatMap(*branchPassFlag, "--branch-pass")
+	for code := range branchPassThresholds {
+		if _, exists := branchMap[code]; !exists {
+			slog.Warn("Unknown branch code in --branch-pass", "branch", code)
+		}
+	}
+
+	if *tiebreakFlag != "" {
+		tiebreak, err := parseComponentList(*tiebreakFlag)
+		if err != nil {
+			log.Fatalf("Invalid --award-tiebreak: %v", err)
+		}
+		awardTiebreak = tiebreak
+	}
+
+	if *consistencyCompsFlag != "" {
+		major, err := parseComponentList(*consistencyCompsFlag)
+		if err != nil {
+			log.Fatalf("Invalid --consistency-components: %v", err)
+		}
+		majorComponents = major
+	}
+
+	if *rangeFlag != "" {
+		r, err := parseCellRange(*rangeFlag)
+		if err != nil {
+			log.Fatalf("Invalid --range: %v", err)
+		}
+		dataRange = r
+	}
+
+	if *quizColumnsFlag != "" {
+		quizColumns, err := parseColumnLetters(*quizColumnsFlag)
+		if err != nil {
+			log.Fatalf("Invalid --quiz-columns: %v", err)
+		}
+		columns.QuizColumns = quizColumns
+	}
+	if *weeklyColumnsFlag != "" {
+		weeklyColumns, err := parseColumnLetters(*weeklyColumnsFlag)
+		if err != nil {
+			log.Fatalf("Invalid --weekly-columns: %v", err)
+		}
+		columns.WeeklyColumns = weeklyColumns
+	}
+	if *flattenWeeklyFlag && len(columns.WeeklyColumns) == 0 {
+		log.Fatalf("--flatten-weekly requires --weekly-columns")
+	}
+	if *idColumnFlag >= 0 {
+		columns.EmpID = *idColumnFlag
+	}
+	if *compreColumnsFlag != "" {
+		compreColumns, err := parseColumnLetters(*compreColumnsFlag)
+		if err != nil {
+			log.Fatalf("Invalid --compre-columns: %v", err)
+		}
+		columns.CompreColumns = compreColumns
+	}
+	if *compreRuleFlag != "max" && *compreRuleFlag != "latest" {
+		log.Fatalf("Invalid --compre-rule %q: must be \"max\" or \"latest\"", *compreRuleFlag)
+	}
+	if *quizAggFlag != "sum" && *quizAggFlag != "average" {
+		log.Fatalf("Invalid --quiz-aggregate %q: must be \"sum\" or \"average\"", *quizAggFlag)
+	}
+
+	if *gradesFlag != "" {
+		cutoffs, err := parseCutoffs(*gradesFlag)
+		if err != nil {
+			log.Fatalf("Invalid --grades: %v", err)
+		}
+		gradeCutoffs = cutoffs
+	}
+
+	if *targetPassRateFlag < 0 || *targetPassRateFlag > 1 {
+		log.Fatalf("Invalid --target-pass-rate %g: must be a fraction between 0 and 1", *targetPassRateFlag)
+	}
+	if *targetByFlag != "weight" && *targetByFlag != "offset" {
+		log.Fatalf("Invalid --target-by %q: must be \"weight\" or \"offset\"", *targetByFlag)
+	}
+	if *chartFlag != "" && len(gradeCutoffs) == 0 {
+		log.Fatalf("--chart requires --grades")
+	}
+	if *normalizedFlag && *matrixFlag == "" {
+		log.Fatalf("--normalized requires --matrix")
+	}
+	if *branchSummaryOutFlag != "" && !*branchSummaryFlag {
+		log.Fatalf("--branch-summary-out requires --branch-summary-only")
+	}
+	if *preferComputedThreshold < 0 || *preferComputedThreshold > 1 {
+		log.Fatalf("Invalid --prefer-computed-threshold %.2f: must be between 0 and 1", *preferComputedThreshold)
+	}
+	if *compreStrictFlag && !*compreRequiredFlag {
+		log.Fatalf("--compre-strict requires --compre-required")
+	}
+
+	if *appealFlag > 0 && len(gradeCutoffs) == 0 {
+		log.Fatalf("--appeal-margin requires --grades")
+	}
+
+	if *gradePointsFlag != "" {
+		if len(gradeCutoffs) == 0 {
+			log.Fatalf("--grade-points requires --grades")
+		}
+		gradePoints = make(map[string]float64)
+		for _, pair := range strings.Split(*gradePointsFlag, ",") {
+			grade, raw, ok := strings.Cut(pair, "=")
+			value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+			if !ok || err != nil {
+				log.Fatalf("Invalid --grade-points entry %q: expected GRADE=POINTS", pair)
+			}
+			gradePoints[strings.TrimSpace(grade)] = value
+		}
+		for _, c := range gradeCutoffs {
+			if _, ok := gradePoints[c.Grade]; !ok {
+				log.Fatalf("Invalid --grade-points: no grade point for grade %s", c.Grade)
+			}
+		}
+	}
+
+	for _, spec := range branchAliasFlag {
+		if err := addBranchAlias(spec); err != nil {
+			log.Fatalf("Invalid --branch-alias: %v", err)
+		}
+	}
+
+	if *whereFlag != "" {
+		filter, err := parseWhere(*whereFlag)
+		if err != nil {
+			log.Fatalf("Invalid --where: %v", err)
+		}
+		studentFilter = filter
+	}
+
+	if _, err := selectDumpFields(*fieldsFlag); err != nil {
+		log.Fatalf("Invalid --fields: %v", err)
+	}
+	if *dumpFormatFlag != "" && *dumpOutFlag == "" {
+		log.Fatalf("--dump-format requires --dump-out")
+	}
+	if path := *dumpOutFlag; path != "" {
+		if _, err := resolveDumpFormat(path, *dumpFormatFlag); err != nil {
+			log.Fatalf("Invalid --dump-out: %v", err)
+		}
+	}
+	if *schemaFlag != "" && *noHeaderFlag {
+		log.Fatalf("--validate-schema requires a header row and cannot be used with --no-header")
+	}
+	if *strictColumnsFlag && *noHeaderFlag {
+		log.Fatalf("--strict-columns requires a header row and cannot be used with --no-header")
+	}
+	if *branchPrefixLenFlag < 1 {
+		log.Fatalf("Invalid --branch-prefix-len %d: must be at least 1", *branchPrefixLenFlag)
+	}
+	if *detectColumnsFlag && *noHeaderFlag {
+		log.Fatalf("--detect-columns requires a header row and cannot be used with --no-header")
+	}
+	if *detectColumnsFlag && *colOffsetFlag >= 0 {
+		log.Fatalf("--detect-columns and --col-offset cannot be used together")
+	}
+	if *sheetAutoFlag && *noHeaderFlag {
+		log.Fatalf("--sheet-auto requires a header row and cannot be used with --no-header")
+	}
+	if *sheetAutoFlag && *combinedFlag != "" {
+		log.Fatalf("--sheet-auto cannot be used with --combined-report, which reads every sheet")
+	}
+	if *dryRunFlag && *watchDirFlag != "" {
+		log.Fatalf("--dry-run cannot be used with --watch-dir, which moves every file it processes and writes its report")
+	}
+	if *keepGoingFlag && *serveFlag != "" {
+		log.Fatalf("--keep-going cannot be used with --serve, which keeps serving one file")
+	}
+	if *watchDirFlag != "" {
+		outDir := *watchOutFlag
+		if outDir == "" {
+			outDir = filepath.Join(*watchDirFlag, "reports")
+		}
+		log.Fatal(watchDir(*watchDirFlag, outDir))
+	}
+
+	// A dry run lists the profiles among its planned artifacts instead of writing them
+	if !*dryRunFlag {
+		if err := startProfiles(*profileFlag, *memProfileFlag); err != nil {
+			log.Fatalf("Failed to start profiling: %v", err)
+		}
+		defer stopProfiles()
+	}
+
+	if *keepGoingFlag {
+		ok := true
+		err := report(func(w io.Writer) error {
+			ok = runBatch(w, flag.Args())
+			return nil
+		})
+		if err != nil {
+			fatalf("Failed to write %s: %v", *outFlag, err)
+		}
+		if !ok {
+			stopProfiles()
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := runFile(flag.Arg(0), false, report); err != nil {
+		fatalf("Failed to process %s: %v", flag.Arg(0), err)
+	}
+}
+
+// Runs the whole pipeline on one input: its layout, processing, checks, exports and the
+// report printed through report. In a --keep-going batch every export path carries the
+// input's name, so the files of one input do not overwrite another's.
+func runFile(filePath string, batch bool, report func(print func(w io.Writer) error) error) error {
+	exportPath := func(path string) string {
+		if !batch || path == "" {
+			return path
+		}
+		return batchArtifactPath(path, filePath)
+	}
+
+	base := columns
+	if err := prepareLayout(filePath, base); err != nil {
+		return fmt.Errorf("read the layout: %w", err)
+	}
+
+	auditEventCount, auditErr = 0, nil
+	if *auditLogFlag != "" && !*dryRunFlag {
+		closeAudit, err := openAuditLog(exportPath(*auditLogFlag))
+		if err != nil {
+			return fmt.Errorf("open audit log: %w", err)
+		}
+		defer closeAudit()
+		audit(auditStart, 0, "", "processing "+filePath)
+	}
+
+	if *fixTotalsFlag {
+		outPath := exportPath(*fixOutFlag)
+		if outPath == "" {
+			localPath := filePath
+			if isURL(filePath) {
+				localPath = path.Base(filePath)
+			}
+			outPath = strings.TrimSuffix(localPath, filepath.Ext(localPath)) + "-fixed.xlsx"
+		}
+		return report(func(w io.Writer) error {
+			if err := fixTotals(w, filePath, outPath); err != nil {
+				return fmt.Errorf("fix totals: %w", err)
+			}
+			return nil
+		})
+	}
+
+	if *combinedFlag != "" {
+		outPath := exportPath(*combinedFlag)
+		return report(func(w io.Writer) error {
+			if *dryRunFlag {
+				fmt.Fprintf(w, "Dry run: the combined report would be written to %s\n%s\n", outPath, nothingWritten())
+				return nil
+			}
+			if err := writeCombinedReport(filePath, outPath, base); err != nil {
+				return fmt.Errorf("write combined report: %w", err)
+			}
+			fmt.Fprintf(w, "Combined report written to %s\n", outPath)
+			return nil
+		})
+	}
+
+	if *countOnlyFlag {
+		count, err := countValidRows(filePath)
+		if err != nil {
+			return fmt.Errorf("count rows: %w", err)
+		}
+		return report(func(w io.Writer) error {
+			_, err := fmt.Fprintln(w, count)
+			return err
+		})
+	}
+
+	started := time.Now()
+	results, err := processFile(filePath)
+	if err != nil {
+		return err
+	}
+	observeProcessing(results, time.Since(started))
+	if err := checkDiscrepancyLimit(results.Students, *failOnDiscrepancyFlag); err != nil {
+		return fmt.Errorf("aborting: %w", err)
+	}
+	if *preferComputedFlag {
+		preferComputedTotals(&results, *preferComputedThreshold)
+	}
+	if *minCountFlag > 0 {
+		dropped := dropSmallBranches(&results, *minCountFlag)
+		for _, group := range sortedBranches(dropped) {
+			recordFinding(levelInfo, "Excluded branch %s (%s) from all reports: %d students, below --min-count %d",
+				group, branchName(group), dropped[group], *minCountFlag)
+		}
+	}
+	componentStats = results.ComponentStats
+	if pct := results.SkippedPercent(); pct > *maxSkippedFlag {
+		return fmt.Errorf("aborting: %d of %d data rows skipped (%.2f%%), exceeding --max-skipped %.2f%%; statistics would be unreliable",
+			results.Skipped(), results.TotalCount+results.Skipped(), pct, *maxSkippedFlag)
+	}
+	checkColumnRanges(results.Students)
+	checkCurvedValues(results.Students, *allowCurveFlag)
+	checkInconsistentScores(results.Students, *consistencyTotalFlag, majorComponents)
+	checkGrossDiscrepancies(results.Students, *grossFlag)
+	audit(auditComplete, 0, "", fmt.Sprintf("%d students accepted, %d rows skipped (%d repeated headers), %d excluded", results.TotalCount, results.Skipped()+results.SkippedSummary+results.SkippedHeaders, results.SkippedHeaders, results.Filtered))
+	if auditErr != nil {
+		return fmt.Errorf("write audit log: %w", auditErr)
+	}
+
+	dumpColumns, err := selectDumpFields(*fieldsFlag)
+	if err != nil {
+		return err
+	}
+	if *flattenWeeklyFlag {
+		dumpColumns = withWeekFields(dumpColumns, len(columns.WeeklyColumns))
+	}
+	if *componentRanksFlag {
+		dumpColumns = withComponentRanks(dumpColumns, results.Students)
+	}
+
+	if *dryRunFlag {
+		return report(func(w io.Writer) error { return printPlannedArtifacts(w, filePath, results, exportPath) })
+	}
+
+	if *webhookFlag != "" {
+		notifyWebhook(*webhookFlag, filePath, results)
+	}
+
+	if *dbFlag != "" {
+		runLabel := *runLabelFlag
+		if runLabel == "" {
+			runLabel = filepath.Base(filePath)
+		}
+		if err := storeRun(*dbFlag, runLabel, results.Students); err != nil {
+			return fmt.Errorf("store results in %s: %w", *dbFlag, err)
+		}
+	}
+
+	if path := exportPath(*dumpOutFlag); path != "" {
+		if err := dumpStudents(path, *dumpFormatFlag, results.Students, dumpColumns); err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+	}
+
+	if dir := exportPath(*perBranchDirFlag); dir != "" {
+		if err := dumpPerBranch(dir, results.Students, dumpColumns); err != nil {
+			return fmt.Errorf("write per-branch files: %w", err)
+		}
+	}
+
+	if path := exportPath(*dumpDiscFlag); path != "" {
+		if err := writeDiscrepancies(path, results.Students); err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+	}
+
+	if path := exportPath(*matrixFlag); path != "" {
+		if err := writeComponentMatrix(path, results.Students, *normalizedFlag); err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+	}
+
+	if path := exportPath(*chartFlag); path != "" {
+		if err := writeGradeChart(path, results.Students); err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+	}
+
+	if dir := exportPath(*reportCardsFlag); dir != "" {
+		if err := writeReportCards(dir, *cardFormatFlag, results.Students); err != nil {
+			return fmt.Errorf("write report cards: %w", err)
+		}
+	}
+
+	if path := exportPath(*summaryJSONFlag); path != "" {
+		if err := writeJSONFile(path, buildSummary(results)); err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+	}
+
+	if path := exportPath(*skippedOutFlag); path != "" {
+		if err := writeSkippedRows(path, results.SkippedRows); err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+	}
+
+	if path := exportPath(*saveBaseFlag); path != "" {
+		if err := saveBaseline(path, results.Students); err != nil {
+			return fmt.Errorf("write baseline: %w", err)
+		}
+	}
+
+	if *serveFlag != "" {
+		return fmt.Errorf("server stopped: %w", serve(*serveFlag, results, base))
+	}
+
+	if *branchSummaryFlag {
+		if *branchSummaryOutFlag == "" {
+			return report(func(w io.Writer) error {
+				printBranchSummary(w, branchSummaryRows(results.Students, *branchMetric, *minBranchSize))
+				return nil
+			})
+		}
+		if err := writeBranchSummary(exportPath(*branchSummaryOutFlag), results.Students); err != nil {
+			return fmt.Errorf("write branch summary: %w", err)
+		}
+		return nil
+	}
+
+	return report(func(w io.Writer) error { return printReport(w, results, base) })
+}
+
+// Prints the report the flags select: one of the single-purpose views, or the summary in
+// --format. base is the layout before the input's own adjustments, for reading --compare.
+func printReport(w io.Writer, results Results, base ColumnSpec) error {
+	switch {
+	case *explainFlag != "":
+		printExplanation(w, results.Students, *explainFlag)
+	case *sampleFlag > 0:
+		seed := *seedFlag
+		if seed == 0 {
+			seed = uint64(time.Now().UnixNano())
+		}
+		printSample(w, results.Students, *sampleFlag, seed)
+	case *neededForFlag != "":
+		printNeededForTarget(w, results.Students, *neededForFlag)
+	case *targetPassRateFlag > 0:
+		printTargetPassRate(w, results.Students, *targetByFlag, *targetPassRateFlag)
+	case *simulateFlag != "":
+		cutoffs, err := parseCutoffs(*simulateFlag)
+		if err != nil {
+			return fmt.Errorf("invalid --simulate-cutoff: %w", err)
+		}
+		printCutoffSimulation(w, results.Students, cutoffs, *boundaryFlag)
+	case *suggestFlag != 0:
+		printSuggestedCutoffs(w, results.Students, *suggestFlag, *cutoffMethod)
+	case *formatFlag == "json":
+		return printJSON(w, buildSummary(results))
+	case *formatFlag == "csv":
+		return writeSummaryCSV(w, buildSummary(results))
+	case *formatFlag == "json-full":
+		return printFullJSON(w, results)
+	case *formatFlag == "md":
+		printMarkdown(w, results)
+	default:
+		if err := printResults(w, results, base); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "\nGenerated: %s\n", generatedAt())
+		if *reportHashFlag {
+			fmt.Fprintf(w, "\nReport Hash: %s\n", reportHash(results))
+		}
+	}
+	return nil
+}
+
+// Parses a comma-separated list of key=value pairs with numeric values
+func parseFloatMap(spec, flagName string) map[string]float64 {
+	values := make(map[string]float64)
+	if spec == "" {
+		return values
+	}
+	for _, pair := range strings.Split(spec, ",") {
+		key, raw, ok := strings.Cut(pair, "=")
+		if !ok {
+			log.Fatalf("Invalid %s entry %q: expected key=value", flagName, pair)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			log.Fatalf("Invalid %s value for %s: %v", flagName, key, err)
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	return values
+}
+
+// Reports whether pass rates were requested
+func passEnabled() bool {
+	return *passFlag > 0 || len(branchPassThresholds) > 0
+}
+
+// Returns the pass threshold for a branch, falling back to the global --pass value
+func passThreshold(branch string) float64 {
+	if threshold, exists := branchPassThresholds[branch]; exists {
+		return threshold
+	}
+	return *passFlag
+}
+
+// Streams the rows of the first sheet to fn one at a time, clipped to --range, without
+// materializing the whole sheet. The index passed to fn is relative to the data region.
+func forEachRow(filePath string, fn func(i int, row []string)) error {
+	return scanRows(filePath, func(i int, row []string) bool {
+		fn(i, row)
+		return true
+	})
+}
+
+// Components that must be non-blank for a row to be valid, from --required
+var requiredComponents []component
+
+// Returns the cells a component is read from, or nil for components built by --merge-components
+func componentColumns(key string) []int {
+	pick := func(single int, several []int) []int {
+		if len(several) > 0 {
+			return several
+		}
+		return []int{single}
+	}
+	switch key {
+	case "quiz":
+		return pick(columns.Quiz, columns.QuizColumns)
+	case "midsem":
+		return []int{columns.MidSem}
+	case "labtest":
+		return []int{columns.LabTest}
+	case "weekly":
+		return pick(columns.WeeklyLabs, columns.WeeklyColumns)
+	case "compre":
+		return pick(columns.Compre, columns.CompreColumns)
+	case "total":
+		return []int{columns.Total}
+	}
+	return nil
+}
+
+// Returns the keys of the components whose cells are all blank in the row
+func blankComponents(row []string) []string {
+	var blank []string
+	for _, comp := range components {
+		cells := componentColumns(comp.key)
+		if cells == nil {
+			continue
+		}
+		present := false
+		for _, idx := range cells {
+			if idx < len(row) {
+				_, ok, _ := parseMark(row[idx])
+				present = present || ok
+			}
+		}
+		if !present {
+			blank = append(blank, comp.key)
+		}
+	}
+	return blank
+}
+
+// Returns the keys of the --required components the student left blank
+func missingRequired(s Student) []string {
+	var missing []string
+	for _, comp := range requiredComponents {
+		if slices.Contains(s.Blank, comp.key) {
+			missing = append(missing, comp.key)
+		}
+	}
+	return missing
+}
+
+// Sheet holding the data rows, or empty for the first sheet
+var dataSheet string
+
+// Package state that reading one workbook changes: the findings, the branch names merged
+// from its Branches sheet, its identifier label, data sheet and column layout
+type runState struct {
+	findings      []Finding
+	branchMap     map[string]string
+	sheetBranches map[string]bool
+	idLabel       string
+	dataSheet     string
+	columns       ColumnSpec
+}
+
+// Captures the current run state, so that uploads and batch files can each start from it
+func saveRunState() runState {
+	return runState{
+		findings:      slices.Clone(findings),
+		branchMap:     maps.Clone(branchMap),
+		sheetBranches: sheetBranches,
+		idLabel:       idLabel,
+		dataSheet:     dataSheet,
+		columns:       columns,
+	}
+}
+
+// Puts back a state captured by saveRunState, dropping whatever was read since; the
+// state stays intact for restoring again
+func (s runState) restore() {
+	findings = slices.Clone(s.findings)
+	branchMap = maps.Clone(s.branchMap)
+	sheetBranches = s.sheetBranches
+	idLabel = s.idLabel
+	dataSheet = s.dataSheet
+	columns = s.columns
+}
+
+// Returns the name of the workbook's data sheet: dataSheet when set, or the first sheet
+func dataSheetName(f *excelize.File) string {
+	if dataSheet != "" {
+		return dataSheet
+	}
+	return f.GetSheetName(0)
+}
+
+// Like forEachRow, but stops reading as soon as fn returns false
+func scanRows(filePath string, fn func(i int, row []string) bool) error {
+	if isODS(filePath) {
+		return scanODSRows(filePath, fn)
+	}
+
+	f, err := openWorkbook(filePath)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+	defer f.Close()
+	return scanWorkbook(f, fn)
+}
+
+// Streams the rows of an open workbook's data sheet to fn, stopping when it returns false
+func scanWorkbook(f *excelize.File, fn func(i int, row []string) bool) error {
+	if err := loadBranchSheet(f); err != nil {
+		return err
+	}
+
+	sheetName := dataSheetName(f)
+	rows, err := f.Rows(sheetName)
+	if err != nil {
+		return fmt.Errorf("read rows: %w", err)
+	}
+	defer rows.Close()
+
+	_, originRow := rangeOrigin()
+	for r := 0; rows.Next(); r++ {
+		if r%memoryCheckInterval == 0 {
+			checkMemory(r)
+		}
+		if dataRange != nil && r >= dataRange.toRow {
+			break
+		}
+		if !rowInRange(r) {
+			continue
+		}
+		row, err := rows.Columns()
+		if err != nil {
+			return fmt.Errorf("read row %d: %w", r+1, err)
+		}
+		i := r - (originRow - 1)
+		if isHeaderRow(i) {
+			row = fillMergedHeader(f, sheetName, r, row)
+		}
+		if !fn(i, clipRow(row)) {
+			return nil
+		}
+	}
+	if err := rows.Error(); err != nil {
+		return fmt.Errorf("read rows: %w", err)
+	}
+	return nil
+}
+
+// Rows read between checks against --max-memory
+const memoryCheckInterval = 5000
+
+// Aborts when the memory obtained from the OS, less what was returned, exceeds --max-memory.
+// debug.SetMemoryLimit is only a soft limit, so this check is what enforces the flag.
+func checkMemory(row int) {
+	if *maxMemoryFlag <= 0 {
+		return
+	}
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	used := (m.Sys - m.HeapReleased) / (1 << 20)
+	if used > uint64(*maxMemoryFlag) {
+		fatalf("Aborting at row %d: using %d MB, above --max-memory %d MB. Narrow the input with --range, use --count-only, or raise the limit.",
+			row+1, used, *maxMemoryFlag)
+	}
+}
+
+// Merges code-to-name mappings from the workbook's Branches sheet, if present, into branchMap
+func loadBranchSheet(f *excelize.File) error {
+	if idx, err := f.GetSheetIndex(branchSheetName); err != nil || idx == -1 {
+		return nil
+	}
+	rows, err := f.GetRows(branchSheetName)
+	if err != nil {
+		return fmt.Errorf("read %s sheet: %w", branchSheetName, err)
+	}
+	applyBranchRows(rows)
+	return nil
+}
+
+// Merges code-to-name rows of a Branches sheet, after its header, into branchMap
+func applyBranchRows(rows [][]string) {
+	sheetBranches = make(map[string]bool)
+	for i, row := range rows {
+		if i == 0 || len(row) < 2 {
+			continue
+		}
+		code := strings.TrimSpace(row[0])
+		name := strings.TrimSpace(row[1])
+		if code == "" || name == "" {
+			continue
+		}
+		if *normalizeBranchNamesFlag {
+			name = normalizeBranchName(name)
+		}
+		branchMap[code] = name
+		sheetBranches[code] = true
+	}
+}
+
+// Degree prefixes whose casing title casing would lose
+var branchNameWords = map[string]string{"msc": "MSc", "mnc": "MnC"}
+
+// Title-cases each word of a branch name, keeping acronyms such as CSE and spelling
+// degree prefixes consistently, e.g. "msc  biology 2021" becomes "MSc Biology 2021"
+func normalizeBranchName(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		if fixed, ok := branchNameWords[strings.ToLower(word)]; ok {
+			words[i] = fixed
+			continue
+		}
+		if word == strings.ToUpper(word) {
+			continue
+		}
+		words[i] = strings.ToUpper(word[:1]) + strings.ToLower(word[1:])
+	}
+	return strings.Join(words, " ")
+}
+
+// Reports whether a row is a summary line such as "Average" or "Max", identified by a
+// --skip-labels label in any cell up to and including the EmpID column
+func isSummaryRow(row []string) bool {
+	for i := 0; i <= columns.EmpID && i < len(row); i++ {
+		cell := cleanCell(row[i])
+		for _, label := range strings.Split(*skipLabelsFlag, ",") {
+			if label = strings.TrimSpace(label); label != "" && strings.EqualFold(cell, label) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
-// Branch name mapping
-var branchMap = map[string]string{
-	"2021A2": "Civil 2021", "2024A3": "EEE 2024", "2024A4": "Mechanical 2024",
-	"2024A5": "Pharma 2024", "2024A7": "CSE 2024", "2024A8": "ENI 2024", "2024AA": "ECE 2024",
-	"2024AD": "MnC 2024", "2024B1": "MSc Biology", "2020B5": "MSc Physics 2020", "2021A7": "CSE 2021", "2022A7": "CSE 2022",
-	"2023A7": "CSE 2023", "2021A8": "ENI 2021", "2021AA": "ECE 2021", "2021B1": "Msc Biology 2021", "2021B4": "Msc Maths 2021",
-	"2021B5": "Msc Physics 2021", "2022A1": "Chemical 2022", "2022A2": "Civil 2022", "2022A3": "EEE 2022", "2022A4": "Mechanical 2022",
-	"2022AA": "ECE 2022", "2022B2": "MSc Chemistry 2022", "2023A5": "Pharma 2023", "2023A8": "ENI 2023",
+// Uses the header of the --id-column column as the identifier label in output
+func captureIDLabel(header []string) {
+	if *idColumnFlag < 0 || columns.EmpID >= len(header) {
+		return
+	}
+	if label := cleanCell(header[columns.EmpID]); label != "" {
+		idLabel = label
+	}
 }
 
-const tolerance = 0.01 // handling floating point precision
+// Reports whether row index i is the header row to skip
+func isHeaderRow(i int) bool {
+	return i == 0 && !*noHeaderFlag
+}
 
-func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage - go run main.go <path-to-file.xlsx>")
-		os.Exit(1)
+// Reports whether a row has neither an identifier nor a campus ID, such as an unused row
+// of a --template sheet that only holds formulas
+func isUnusedRow(row []string) bool {
+	for _, idx := range []int{columns.EmpID, columns.CampusID} {
+		if idx < len(row) && cleanCell(row[idx]) != "" {
+			return false
+		}
 	}
+	return true
+}
 
-	filePath := os.Args[1]
-
-	students, branchSums, branchCounts, totalSum, totalCount := processFile(filePath)
+// Reports whether row repeats the header, as happens when exports are concatenated,
+// by comparing the cells of every mapped column
+func isRepeatedHeader(row, header []string) bool {
+	if header == nil {
+		return false
+	}
+	for _, idx := range columns.indices() {
+		if idx >= len(row) || idx >= len(header) || header[idx] == "" {
+			return false
+		}
+		if !strings.EqualFold(cleanCell(row[idx]), header[idx]) {
+			return false
+		}
+	}
+	return true
+}
 
-	printResults(students, branchSums, branchCounts, totalSum, totalCount)
+// Counts rows that would parse as valid students without converting any marks
+func countValidRows(filePath string) (int, error) {
+	count := 0
+	err := forEachRow(filePath, func(i int, row []string) {
+		if isHeaderRow(i) || isUnusedRow(row) || isSummaryRow(row) || len(row) < minColumns() {
+			return
+		}
+		if branch, _ := branchOf(cleanCells(row)); branch == "" {
+			return
+		}
+		count++
+	})
+	return count, err
 }
 
+// Returned by processFile when the sheet holds no usable student rows
+var errNoStudents = errors.New("no student data found")
+
 // Processes the Excel file and returns the necessary data
-func processFile(filePath string) ([]Student, map[string]float64, map[string]int, float64, int) {
-	f, err := excelize.OpenFile(filePath)
+func processFile(filePath string) (Results, error) {
+	return processRows(func(fn func(i int, row []string) bool) error {
+		return scanRows(filePath, fn)
+	})
+}
+
+// Processes an .xlsx workbook read from r, such as an in-memory fixture
+func processReader(r io.Reader) (Results, error) {
+	f, err := excelize.OpenReader(r, excelize.Options{Password: workbookPassword()})
 	if err != nil {
-		log.Fatalf("Failed to open file: %v", err)
+		return Results{}, fmt.Errorf("open workbook: %w", err)
 	}
 	defer f.Close()
+	return processRows(func(fn func(i int, row []string) bool) error {
+		return scanWorkbook(f, fn)
+	})
+}
 
-	sheetName := f.GetSheetName(0)
-	rows, err := f.GetRows(sheetName)
-	if err != nil {
-		log.Fatalf("Failed to read rows: %v", err)
+// Records an error finding for each mark cell of s that could not be parsed
+func reportMalformed(s Student, malformed []malformedCell) {
+	for _, cell := range malformed {
+		recordFinding(levelError, "%s %s: could not parse %s value %q", idLabel, s.EmpID, cell.column, cell.value)
 	}
+}
 
-	var students []Student
-	branchSums := make(map[string]float64)
-	branchCounts := make(map[string]int)
-	var totalSum float64
-	var totalCount int
+// Parses the rows produced by scan into results, independently of where they come from
+func processRows(scan func(fn func(i int, row []string) bool) error) (Results, error) {
+	_, originRow := rangeOrigin()
 
-	for i, row := range rows {
-		if i == 0 || len(row) < 10 {
-			continue
+	var results Results
+	agg := newAggregator()
+	empIDBranchFallbacks = 0
+	invertedRows = 0
+	missingBranches := make(map[string]bool)
+	blankCounts := make(map[string]int)
+	var header []string
+
+	err := scan(func(i int, row []string) bool {
+		if isHeaderRow(i) {
+			captureIDLabel(row)
+			header = cleanCells(row)
+			return true
+		}
+		if len(row) == 0 || isUnusedRow(row) {
+			return true
+		}
+		if isRepeatedHeader(row, header) {
+			audit(auditSkip, originRow+i, "", skipHeaderRow)
+			results.SkippedHeaders++
+			results.SkippedRows = append(results.SkippedRows, SkippedRow{Row: originRow + i, Reason: skipHeaderRow, Cells: row})
+			return true
+		}
+		if isSummaryRow(row) {
+			audit(auditSkip, originRow+i, "", skipSummaryRow)
+			results.SkippedSummary++
+			results.SkippedRows = append(results.SkippedRows, SkippedRow{Row: originRow + i, Reason: skipSummaryRow, Cells: row})
+			recordFinding(levelWarning, "Skipping summary row %d", originRow+i)
+			return true
+		}
+		if len(row) < minColumns() {
+			audit(auditSkip, originRow+i, "", skipShortRow)
+			results.SkippedShort++
+			results.SkippedRows = append(results.SkippedRows, SkippedRow{Row: originRow + i, Reason: skipShortRow, Cells: row})
+			return true
 		}
 
-		student, valid := parseRow(row)
+		student, malformed, valid := parseRow(row)
 		if !valid {
+			audit(auditSkip, originRow+i, "", fmt.Sprintf("%s %q", skipInvalidBranch, cleanCell(row[columns.CampusID])))
+			results.SkippedBranch++
+			results.SkippedRows = append(results.SkippedRows, SkippedRow{Row: originRow + i, Reason: skipInvalidBranch, Cells: row})
+			return true
+		}
+
+		student.Row = originRow + i
+		reportMalformed(student, malformed)
+		if len(malformed) > 0 && *skipMalformedFlag {
+			audit(auditSkip, student.Row, student.EmpID, fmt.Sprintf("%s in %s", skipMalformed, malformed[0].column))
+			results.SkippedMalformed++
+			results.SkippedRows = append(results.SkippedRows, SkippedRow{Row: student.Row, Reason: skipMalformed, Cells: row})
+			return true
+		}
+		for _, key := range student.Blank {
+			blankCounts[key]++
+		}
+		if campusID := cleanCell(row[columns.CampusID]); extractBranch(campusID) == "" {
+			audit(auditNormalize, student.Row, student.EmpID, fmt.Sprintf("branch %s taken from %s because campus ID %q has none", student.Branch, idLabel, campusID))
+		}
+		if raw := cleanCell(row[columns.EmpID]); raw != student.EmpID {
+			audit(auditNormalize, student.Row, student.EmpID, fmt.Sprintf("EmpID normalized from %q", raw))
+		}
+		if calculated := computedTotal(student); !isWithinTolerance(calculated, student.Total) {
+			audit(auditDiscrepancy, student.Row, student.EmpID, fmt.Sprintf("total %.2f differs from component sum %.2f", student.Total, calculated))
+		}
+
+		if hasNoComponents(student) {
+			results.NoComponents = append(results.NoComponents, student.EmpID)
+			if *requireCompsFlag {
+				audit(auditExclude, student.Row, student.EmpID, "total without component marks, dropped by --require-components")
+				return true
+			}
+		}
+
+		if *compreRequiredFlag && student.Compre == 0 {
+			results.MissingCompre = append(results.MissingCompre, student.EmpID)
+			if *compreStrictFlag {
+				audit(auditExclude, student.Row, student.EmpID, "no compre score, dropped by --compre-strict")
+				return true
+			}
+		}
+
+		if missing := missingRequired(student); len(missing) > 0 {
+			if results.MissingRequired == nil {
+				results.MissingRequired = make(map[string][]string)
+			}
+			for _, key := range missing {
+				results.MissingRequired[key] = append(results.MissingRequired[key], student.EmpID)
+			}
+			if *requiredStrictFlag {
+				audit(auditExclude, student.Row, student.EmpID, fmt.Sprintf("blank %s, dropped by --required-strict", strings.Join(missing, ", ")))
+				return true
+			}
+		}
+
+		if studentFilter != nil && !studentFilter(student) {
+			audit(auditExclude, student.Row, student.EmpID, "does not match --where")
+			results.Filtered++
+			return true
+		}
+
+		if sheetBranches != nil && !sheetBranches[student.Branch] {
+			missingBranches[student.Branch] = true
+		}
+
+		results.Students = append(results.Students, student)
+		agg.Add(student)
+		return true
+	})
+	if err != nil {
+		return Results{}, err
+	}
+	if len(results.Students) == 0 {
+		if results.Filtered > 0 {
+			return Results{}, fmt.Errorf("%w: all %d valid rows were excluded by --where", errNoStudents, results.Filtered)
+		}
+		return Results{}, errNoStudents
+	}
+	results.setAggregate(agg.Result())
+
+	for branch := range missingBranches {
+		recordFinding(levelWarning, "Branch %s is not listed in the %s sheet, using built-in name %q", branch, branchSheetName, branchMap[branch])
+	}
+	for _, comp := range requiredComponents {
+		delete(blankCounts, comp.key)
+	}
+	for _, comp := range components {
+		if n := blankCounts[comp.key]; n > 0 {
+			recordFinding(levelInfo, "%s was blank for %d student(s) and read as 0", comp.name(), n)
+		}
+	}
+	if invertedRows > 0 {
+		recordFinding(levelWarning, "%d row(s) have a numeric %s but text in every mark column; the columns are likely transposed, so check the column layout",
+			invertedRows, idLabel)
+	}
+	if empIDBranchFallbacks > 0 {
+		recordFinding(levelInfo, "Took the branch from the %s for %d row(s) without a usable campus ID", idLabel, empIDBranchFallbacks)
+	}
+	if n := len(results.NoComponents); n > 0 {
+		action := "kept; use --require-components to drop them"
+		if *requireCompsFlag {
+			action = "dropped by --require-components"
+		}
+		recordFinding(levelWarning, "%d student(s) have a Total but every component is zero (%s): %s",
+			n, action, strings.Join(results.NoComponents, ", "))
+	}
+	for _, comp := range requiredComponents {
+		missing := results.MissingRequired[comp.key]
+		if len(missing) == 0 {
 			continue
 		}
+		action := "kept; use --required-strict to drop them"
+		if *requiredStrictFlag {
+			action = "dropped by --required-strict"
+		}
+		recordFinding(levelWarning, "%d student(s) have a blank %s (%s): %s",
+			len(missing), comp.label, action, strings.Join(missing, ", "))
+	}
+	if n := len(results.MissingCompre); n > 0 {
+		action := "kept; use --compre-strict to drop them"
+		if *compreStrictFlag {
+			action = "dropped by --compre-strict"
+		}
+		recordFinding(levelWarning, "%d student(s) are missing a compre score (%s): %s",
+			n, action, strings.Join(results.MissingCompre, ", "))
+	}
+	if results.SkippedHeaders > 0 {
+		recordFinding(levelWarning, "Skipped %d repeated header row(s) inside the data", results.SkippedHeaders)
+	}
+
+	return results, nil
+}
 
-		students = append(students, student)
-		branchSums[student.Branch] += student.Total
-		branchCounts[student.Branch]++
-		totalSum += student.Total
-		totalCount++
+// Removes branches with fewer than minCount students from the results and returns the
+// student count of each removed branch
+func dropSmallBranches(results *Results, minCount int) map[string]int {
+	dropped := make(map[string]int)
+	for group, count := range results.BranchCounts {
+		if count < minCount {
+			dropped[group] = count
+		}
+	}
+	if len(dropped) == 0 {
+		return dropped
 	}
 
-	return students, branchSums, branchCounts, totalSum, totalCount
+	var kept []Student
+	for _, s := range results.Students {
+		if _, ok := dropped[branchGroup(s.Branch)]; ok {
+			continue
+		}
+		kept = append(kept, s)
+	}
+	results.Students = kept
+	results.setAggregate(aggregate(kept).Result())
+	return dropped
 }
 
-// Parses a row from the Excel file and returns a Student struct and a validity flag
-func parseRow(row []string) (Student, bool) {
-	empID := row[2]
-	campusID := row[3]
-	quiz, _ := strconv.ParseFloat(row[4], 64)
-	midSem, _ := strconv.ParseFloat(row[5], 64)
-	labTest, _ := strconv.ParseFloat(row[6], 64)
-	weeklyLabs, _ := strconv.ParseFloat(row[7], 64)
-	compre, _ := strconv.ParseFloat(row[9], 64)
-	total, _ := strconv.ParseFloat(row[10], 64)
+// A mark cell holding text that does not parse as a number
+type malformedCell struct {
+	column string
+	value  string
+}
 
-	branch := extractBranch(campusID)
-	if len(branch) < 6 {
-		log.Printf("Skipping row due to invalid branch ID: %s\n", campusID)
-		return Student{}, false
+// Returns the mark cells of a cleaned row holding text that is not a number, such as "NA".
+// Blank cells are not malformed; they are recorded in Student.Blank instead.
+func malformedCells(row []string) []malformedCell {
+	var malformed []malformedCell
+	for _, comp := range components {
+		for _, idx := range componentColumns(comp.key) {
+			if idx >= len(row) {
+				continue
+			}
+			if _, _, err := parseMark(row[idx]); err != nil {
+				malformed = append(malformed, malformedCell{comp.label, row[idx]})
+			}
+		}
 	}
+	return malformed
+}
 
-	preCompre := quiz + midSem + labTest + weeklyLabs
-	calculatedTotal := preCompre + compre
+// Parses a row from the Excel file and returns a Student struct, the mark cells that
+// could not be parsed and were read as 0, and a validity flag
+func parseRow(row []string) (Student, []malformedCell, bool) {
+	row = cleanCells(row)
+	if looksInverted(row) {
+		invertedRows++
+	}
+	empID := row[columns.EmpID]
+	if *normalizeIDFlag {
+		if normalized := normalizeEmpID(empID); normalized != empID {
+			recordFinding(levelWarning, "Normalized %s %q to %q", idLabel, empID, normalized)
+			empID = normalized
+		}
+	}
+	campusID := row[columns.CampusID]
+	quiz, _, _ := parseMark(row[columns.Quiz])
+	if len(columns.QuizColumns) > 0 {
+		k := *quizBestOfFlag
+		if k <= 0 {
+			k = len(columns.QuizColumns)
+		}
+		quiz = bestOfQuizzes(row, columns.QuizColumns, k, *quizAggFlag)
+	}
+	midSem, _, _ := parseMark(row[columns.MidSem])
+	labTest, _, _ := parseMark(row[columns.LabTest])
+	weeklyLabs, _, _ := parseMark(row[columns.WeeklyLabs])
+	var weeks []float64
+	if len(columns.WeeklyColumns) > 0 {
+		weeks = weeklyScores(row, columns.WeeklyColumns)
+		weeklyLabs = 0
+		for _, score := range weeks {
+			weeklyLabs += score
+		}
+	}
+	compre, _, _ := parseMark(row[columns.Compre])
+	compreAttempt := 0
+	if len(columns.CompreColumns) > 0 {
+		compre, compreAttempt, _ = selectCompre(row, columns.CompreColumns, *compreRuleFlag)
+	}
+	total, _, _ := parseMark(row[columns.Total])
 
-	if !isWithinTolerance(calculatedTotal, total) {
-		log.Printf("Discrepancy in total marks for EmpID %s: Expected %.2f, Found %.2f\n",
-			empID, calculatedTotal, total)
+	branch, fromEmpID := branchOf(row)
+	if fromEmpID {
+		empIDBranchFallbacks++
+	}
+	if len(branch) < *branchPrefixLenFlag {
+		recordFinding(levelWarning, "Skipping row due to invalid branch ID: %s", campusID)
+		return Student{}, nil, false
 	}
 
 	student := Student{
@@ -122,69 +1841,496 @@ func parseRow(row []string) (Student, bool) {
 		WeeklyLabs: weeklyLabs,
 		Compre:     compre,
 		Total:      total,
+
+		CompreAttempt: compreAttempt,
+		Weeks:         weeks,
+		Blank:         blankComponents(row),
+	}
+
+	calculatedTotal := computedTotal(student)
+	if !isWithinTolerance(calculatedTotal, total) {
+		recordFinding(levelError, "Discrepancy in total marks for %s %s: Expected %.2f, Found %.2f",
+			idLabel, empID, calculatedTotal, total)
+	}
+
+	return student, malformedCells(row), true
+}
+
+// Reports whether a student has a nonzero Total but zero in every component, meaning the
+// Total was entered without its breakdown
+func hasNoComponents(s Student) bool {
+	return s.Total != 0 && s.Quiz == 0 && s.MidSem == 0 && s.LabTest == 0 && s.WeeklyLabs == 0 && s.Compre == 0
+}
+
+// Returns the sum of all components for a student
+func computedTotal(s Student) float64 {
+	return preCompreTotal(s) + s.Compre
+}
+
+// Set when --prefer-computed replaced the sheet's totals with the component sums
+var usingComputedTotals bool
+
+// Replaces every Total with the component sum and recomputes the statistics when more
+// than threshold of the students have a Total that disagrees with their components,
+// the signature of a Total column that was not recalculated
+func preferComputedTotals(results *Results, threshold float64) {
+	stale := 0
+	for _, s := range results.Students {
+		if !isWithinTolerance(computedTotal(s), s.Total) {
+			stale++
+		}
+	}
+	if len(results.Students) == 0 || float64(stale)/float64(len(results.Students)) <= threshold {
+		return
+	}
+
+	for i := range results.Students {
+		results.Students[i].Total = computedTotal(results.Students[i])
+	}
+	results.setAggregate(aggregate(results.Students).Result())
+	usingComputedTotals = true
+	recordFinding(levelWarning, "%d of %d totals disagree with their components (over %.0f%%); the Total column looks stale, so computed totals are used for all ranking and statistics",
+		stale, len(results.Students), 100*threshold)
+}
+
+// Returns the sum of the components assessed before compre
+func preCompreTotal(s Student) float64 {
+	return s.Quiz + s.MidSem + s.LabTest + s.WeeklyLabs
+}
+
+// Writes the computed total into every Total cell that disagrees with it, saves the workbook
+// to outPath and reports what it did to w
+func fixTotals(w io.Writer, filePath, outPath string) error {
+	f, err := openWorkbook(filePath)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+	defer f.Close()
+
+	if err := loadBranchSheet(f); err != nil {
+		return fmt.Errorf("load branches: %w", err)
 	}
 
-	return student, true
+	sheetName := dataSheetName(f)
+	rows, err := f.GetRows(sheetName)
+	if err != nil {
+		return fmt.Errorf("read rows: %w", err)
+	}
+	originCol, originRow := rangeOrigin()
+
+	corrections := 0
+	for i, row := range clipToRange(rows) {
+		if isHeaderRow(i) {
+			captureIDLabel(row)
+			continue
+		}
+		if len(row) < minColumns() {
+			continue
+		}
+
+		student, malformed, valid := parseRow(row)
+		if !valid {
+			continue
+		}
+		// A malformed cell reads as 0, so its recomputed total would be wrong
+		if len(malformed) > 0 {
+			student.Row = originRow + i
+			reportMalformed(student, malformed)
+			audit(auditSkip, student.Row, student.EmpID, fmt.Sprintf("%s in %s", skipMalformed, malformed[0].column))
+			continue
+		}
+
+		calculatedTotal := computedTotal(student)
+		if isWithinTolerance(calculatedTotal, student.Total) {
+			continue
+		}
+
+		cell, err := excelize.CoordinatesToCellName(originCol+columns.Total, originRow+i)
+		if err != nil {
+			return fmt.Errorf("resolve Total cell for row %d: %w", originRow+i, err)
+		}
+		if err := f.SetCellFloat(sheetName, cell, calculatedTotal, 2, 64); err != nil {
+			return fmt.Errorf("write cell %s: %w", cell, err)
+		}
+		verb := "Corrected"
+		if *dryRunFlag {
+			verb = "Would correct"
+		}
+		slog.Info(fmt.Sprintf("%s total for %s %s: %.2f -> %.2f", verb, idLabel, student.EmpID, student.Total, calculatedTotal),
+			"row", originRow+i, "empid", student.EmpID, "from", student.Total, "to", calculatedTotal)
+		corrections++
+	}
+	if auditErr != nil {
+		return auditErr
+	}
+
+	if *dryRunFlag {
+		fmt.Fprintf(w, "Dry run: would correct %d total(s) and save to %s (xlsx, %d rows)\n%s\n", corrections, outPath, len(rows), nothingWritten())
+		return nil
+	}
+	if err := f.SaveAs(outPath); err != nil {
+		return fmt.Errorf("save file: %w", err)
+	}
+	fmt.Fprintf(w, "Corrected %d total(s), saved to %s\n", corrections, outPath)
+	return nil
+}
+
+// Uppercases an EmpID and collapses runs of whitespace to a single space
+func normalizeEmpID(id string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(id), " "))
+}
+
+// Returns a copy of row with every cell cleaned
+func cleanCells(row []string) []string {
+	cleaned := make([]string, len(row))
+	for i, cell := range row {
+		cleaned[i] = cleanCell(cell)
+	}
+	return cleaned
+}
+
+// Strips surrounding whitespace and byte order marks that some exporters leave in cells
+func cleanCell(cell string) string {
+	return strings.TrimFunc(cell, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '\uFEFF'
+	})
+}
+
+// Parses a numeric cell, stripping thousands separators and normalizing the configured
+// decimal separator. A thousands separator is only accepted between groups of three
+// digits, so "83,50" is malformed rather than read as 8350.
+func parseNumber(cell string) (float64, error) {
+	thousandsSep := ","
+	if *decimalSep == "," {
+		thousandsSep = "."
+	}
+	whole, fraction, hasFraction := strings.Cut(cell, *decimalSep)
+	if strings.Contains(whole, thousandsSep) {
+		if !validDigitGroups(strings.TrimLeft(whole, "+-"), thousandsSep) {
+			return 0, fmt.Errorf("%q: %q does not separate groups of three digits", cell, thousandsSep)
+		}
+		whole = strings.ReplaceAll(whole, thousandsSep, "")
+	}
+	if strings.Contains(fraction, thousandsSep) {
+		return 0, fmt.Errorf("%q: %q after the decimal separator", cell, thousandsSep)
+	}
+	if hasFraction {
+		whole += "." + fraction
+	}
+	return strconv.ParseFloat(whole, 64)
+}
+
+// Reports whether digits split on sep form thousands groups: one to three digits, then
+// groups of exactly three
+func validDigitGroups(digits, sep string) bool {
+	groups := strings.Split(digits, sep)
+	for i, group := range groups {
+		if (i == 0 && (len(group) < 1 || len(group) > 3)) || (i > 0 && len(group) != 3) {
+			return false
+		}
+		for _, r := range group {
+			if r < '0' || r > '9' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Parses a mark cell, reporting a blank cell as having no value rather than a mark of
+// zero. Integer and decimal spellings such as "45" and "45.0" parse to the same mark.
+func parseMark(cell string) (float64, bool, error) {
+	cell = strings.TrimSpace(cell)
+	if cell == "" {
+		return 0, false, nil
+	}
+	value, err := parseNumber(cell)
+	return value, true, err
 }
 
-// Extracts branch from Campus ID
+// Extracts branch from Campus ID, whose first --branch-prefix-len characters are the branch code
 func extractBranch(campusID string) string {
-	if len(campusID) < 6 {
+	if len(campusID) < *branchPrefixLenFlag {
 		return ""
 	}
-	branch := campusID[:6]
+	branch := campusID[:*branchPrefixLenFlag]
 	if _, exists := branchMap[branch]; exists {
 		return branch
 	}
 	return ""
 }
 
-// Checks if two floating-point numbers are within a specified tolerance
+// Number of rows whose mark columns all hold text while the EmpID column holds a number
+var invertedRows int
+
+// Reports whether a cleaned row has a numeric EmpID but text in every mark column, the
+// signature of an ID column transposed with the mark columns
+func looksInverted(row []string) bool {
+	if _, err := parseNumber(row[columns.EmpID]); err != nil {
+		return false
+	}
+	for _, idx := range []int{columns.Quiz, columns.MidSem, columns.LabTest, columns.WeeklyLabs, columns.Compre, columns.Total} {
+		if row[idx] == "" {
+			return false
+		}
+		if _, err := parseNumber(row[idx]); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Number of rows whose branch came from the EmpID through --branch-from-empid
+var empIDBranchFallbacks int
+
+// Returns the branch of a cleaned row from its campus ID or, with --branch-from-empid,
+// from its EmpID when the campus ID has none, reporting whether the fallback was used
+func branchOf(row []string) (string, bool) {
+	if branch := extractBranch(row[columns.CampusID]); branch != "" || !*branchFromEmpIDFlag {
+		return branch, false
+	}
+	branch := extractBranch(row[columns.EmpID])
+	return branch, branch != ""
+}
+
+// Checks if two floating-point numbers are within the configured absolute and/or relative tolerance
 func isWithinTolerance(a, b float64) bool {
-	return math.Abs(a-b) <= tolerance
+	diff := math.Abs(a - b)
+	absOK := diff <= *toleranceFlag
+	relOK := diff <= *relToleranceFlag*math.Max(math.Abs(a), math.Abs(b))
+
+	switch *toleranceMode {
+	case "rel":
+		return relOK
+	case "either":
+		return absOK || relOK
+	case "both":
+		return absOK && relOK
+	}
+	return absOK
+}
+
+// Prints the results, reading any --compare workbook from the base layout
+func printResults(w io.Writer, results Results, base ColumnSpec) error {
+	if usingComputedTotals {
+		fmt.Fprintln(w, bold("NOTICE: the Total column looks stale; totals below are computed from the components"))
+	}
+	fmt.Fprintln(w, bold("======================================"))
+	fmt.Fprintln(w, bold(topSectionTitle(len(results.Students))))
+	summary := buildSummary(results)
+	printTopStudents(w, summary, len(results.Students))
+
+	fmt.Fprintln(w, "\n"+bold("======================================"))
+	fmt.Fprintln(w, bold("Overall and Branch-Wise Averages"))
+	averageNote := func(students []Student, gradePoint *float64) string {
+		var note string
+		if *trimFlag > 0 {
+			note += formatTrimmed(totalsOf(students), *trimFlag)
+		}
+		if gradePoint != nil {
+			note += fmt.Sprintf(" (average grade point %.2f)", *gradePoint)
+		}
+		return note
+	}
+	groups := studentsByBranch(results.Students)
+	fmt.Fprintf(w, "Overall Average Marks: %s%s\n", formatAverage(summary.OverallAverage), averageNote(results.Students, summary.AverageGradePoint))
+	if branchCredits != nil {
+		fmt.Fprintf(w, "Credit-Weighted Overall Average Marks: %s\n", formatAverage(summary.CreditWeightedAverage))
+		if len(summary.BranchesWithoutCredits) > 0 {
+			fmt.Fprintf(w, "(branches without --branch-credits left out: %s)\n", strings.Join(summary.BranchesWithoutCredits, ", "))
+		}
+	}
+	for _, b := range summary.BranchAverages {
+		fmt.Fprintf(w, "Branch %s (%s) Average Marks: %s%s\n", b.BranchCode, b.BranchName, formatAverage(b.Average), averageNote(groups[b.BranchCode], b.GradePoint))
+	}
+
+	if passEnabled() {
+		printPassRates(w, results)
+	}
+
+	if *mergeYearsFlag {
+		printMergedYears(w, results.Students)
+	}
+
+	if *statsFlag {
+		printStats(w, results.Students)
+	}
+
+	if *groupReportFlag {
+		printGroupReport(w, results.Students, *topFlag)
+	}
+
+	if *explainDiscFlag {
+		printDiscrepancyBuckets(w, results.Students)
+	}
+
+	if componentPassMarks != nil {
+		printComponentFailures(w, results.Students)
+	}
+
+	if *declineFlag > 0 {
+		printDecliners(w, results.Students, *declineFlag)
+	}
+
+	if *topOverallFlag {
+		printOverallLeaderboard(w, results.Students, *topFlag)
+	}
+
+	if *appealFlag > 0 {
+		printAppealCandidates(w, results.Students, gradeCutoffs, *appealFlag)
+	}
+
+	if *zRankFlag > 0 {
+		printZRanking(w, results.Students, *zRankFlag)
+	}
+
+	if *rankFlag {
+		printBranchRanking(w, results.Students, *branchMetric, *minBranchSize)
+	}
+
+	if *weakestFlag {
+		printWeakestComponents(w, results.Students)
+	}
+	if *contributionFlag {
+		printBranchContributions(w, results)
+	}
+
+	if *baselineFlag != "" {
+		baseline, err := loadBaseline(*baselineFlag)
+		if err != nil {
+			return fmt.Errorf("load baseline: %w", err)
+		}
+		printBaselineComparison(w, results.Students, baseline)
+	}
+
+	if *compareFlag != "" {
+		earlier, err := processEarlier(*compareFlag, base)
+		if err != nil {
+			return fmt.Errorf("process %s: %w", *compareFlag, err)
+		}
+		printRankChanges(w, earlier.Students, results.Students, *compareFlag)
+	}
+	return nil
 }
 
-// Prints the results
-func printResults(students []Student, branchSums map[string]float64, branchCounts map[string]int, totalSum float64, totalCount int) {
-	fmt.Println("======================================")
-	fmt.Println("Top 3 Students for Each Component")
-	printTopStudents(students)
+// Processes an earlier workbook with its own layout, leaving the findings, branch names
+// and layout of the current input untouched
+func processEarlier(filePath string, base ColumnSpec) (Results, error) {
+	saved := saveRunState()
+	defer saved.restore()
+	if err := prepareLayout(filePath, base); err != nil {
+		return Results{}, fmt.Errorf("read the layout: %w", err)
+	}
+	return processFile(filePath)
+}
 
-	fmt.Println("\n======================================")
-	fmt.Println("Overall and Branch-Wise Averages")
-	fmt.Printf("Overall Average Marks: %.2f\n", totalSum/float64(totalCount))
-	for branch, sum := range branchSums {
-		fmt.Printf("Branch %s (%s) Average Marks: %.2f\n", branch, branchMap[branch], sum/float64(branchCounts[branch]))
+// Prints overall and branch-wise pass rates using each branch's threshold
+func printPassRates(w io.Writer, results Results) {
+	fmt.Fprintln(w, "\n"+bold("======================================"))
+	fmt.Fprintln(w, bold("Overall and Branch-Wise Pass Rates"))
+	fmt.Fprintf(w, "Overall Pass Rate: %.2f%% (%d/%d)\n",
+		100*float64(results.TotalPasses)/float64(results.TotalCount), results.TotalPasses, results.TotalCount)
+	for _, branch := range sortedBranches(results.BranchCounts) {
+		count, passes := results.BranchCounts[branch], results.BranchPasses[branch]
+		fmt.Fprintf(w, "Branch %s (%s) Pass Rate: %.2f%% (%d/%d, threshold %.2f)\n",
+			branch, branchName(branch), 100*float64(passes)/float64(count), passes, count, passThreshold(branch))
 	}
 }
 
-// Prints top 3 students for each component
-func printTopStudents(students []Student) {
-	components := []struct {
-		name   string
-		getVal func(Student) float64
-	}{
-		{"Quiz (30)", func(s Student) float64 { return s.Quiz }},
-		{"Mid-Sem (75)", func(s Student) float64 { return s.MidSem }},
-		{"Lab Test (60)", func(s Student) float64 { return s.LabTest }},
-		{"Weekly Labs", func(s Student) float64 { return s.WeeklyLabs }},
-		{"Compre (105)", func(s Student) float64 { return s.Compre }},
-		{"Total (300)", func(s Student) float64 { return s.Total }},
+// Prints the top list of each component from the summary, for a cohort of n students
+func printTopStudents(w io.Writer, summary Summary, n int) {
+	for _, comp := range reportComponents {
+		fmt.Fprintln(w, "\n"+bold(fmt.Sprintf("%s for %s:", topLabel(n), comp.name())))
+		for i, entry := range summary.TopStudents[comp.name()] {
+			line := fmt.Sprintf("%d. %s: %s - %.2f", i+1, idLabel, entry.EmpID, entry.Value)
+			if entry.Raw != nil {
+				line = fmt.Sprintf("%d. %s: %s - %.2f weighted (raw %.2f)", i+1, idLabel, entry.EmpID, entry.Value, *entry.Raw)
+			}
+			if i == 0 {
+				line = highlight(line)
+			}
+			fmt.Fprintln(w, line)
+		}
 	}
+}
 
-	for _, comp := range components {
-		fmt.Printf("\nTop 3 for %s:\n", comp.name)
-		sorted := sortByComponent(students, comp.getVal)
-		for i, s := range sorted[:min(3, len(sorted))] {
-			fmt.Printf("%d. EmpID: %s - %.2f\n", i+1, s.EmpID, comp.getVal(s))
+// Prints the n best students by Total with their branch, rank and grade in one table,
+// ordered and ranked with the --award-tiebreak rules
+func printOverallLeaderboard(w io.Writer, students []Student, n int) {
+	fmt.Fprintln(w, "\n"+bold("======================================"))
+	fmt.Fprintln(w, bold(fmt.Sprintf("Top %d Overall Performers", n)))
+
+	total, _ := componentByKey("total")
+	sorted := rankByComponent(students, total)
+	sorted = sorted[:min(n, len(sorted))]
+	ranks := overallRanks(sorted)
+	fmt.Fprintf(w, "%-6s %-14s %-24s %8s  %s\n", "Rank", idLabel, "Branch", "Total", "Grade")
+	for i, s := range sorted {
+		grade := "-"
+		if len(gradeCutoffs) > 0 {
+			grade = assignGrade(s.Total, gradeCutoffs)
+		}
+		line := fmt.Sprintf("%-6d %-14s %-24s %8.2f  %s", ranks[i], s.EmpID, branchName(branchGroup(s.Branch)), s.Total, grade)
+		if ranks[i] == 1 {
+			line = highlight(line)
 		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// Returns how many of n students each component's top list shows: the --top-percent share
+// rounded up to at least one, or --top
+func componentTopCount(n int) int {
+	if *topPercentFlag <= 0 {
+		return *topFlag
+	}
+	return max(1, int(math.Ceil(*topPercentFlag*float64(n)/100)))
+}
+
+// Returns the title of the component top lists section
+func topSectionTitle(n int) string {
+	if *topPercentFlag <= 0 {
+		return fmt.Sprintf("Top %d Students for Each Component", *topFlag)
+	}
+	return fmt.Sprintf("Top %g%% of Students for Each Component (%d students)", *topPercentFlag, componentTopCount(n))
+}
+
+// Returns the heading for the component top lists, e.g. "Top 3" or "Top 5% (2 students)"
+func topLabel(n int) string {
+	if *topPercentFlag <= 0 {
+		return fmt.Sprintf("Top %d", *topFlag)
+	}
+	return fmt.Sprintf("Top %g%% (%d students)", *topPercentFlag, componentTopCount(n))
+}
+
+// Sorts students by a component, breaking ties on Total with the --award-tiebreak sequence
+func rankByComponent(students []Student, comp component) []Student {
+	if comp.key != "total" || len(awardTiebreak) == 0 {
+		return sortByComponent(students, comp.getVal)
 	}
+	return sortByComposite(students, append([]component{comp}, awardTiebreak...))
+}
+
+// Sorts students descending by each component in turn, moving to the next only on ties
+func sortByComposite(students []Student, keys []component) []Student {
+	sorted := append([]Student{}, students...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		for _, comp := range keys {
+			a, b := comp.getVal(sorted[i]), comp.getVal(sorted[j])
+			if a != b {
+				return a > b
+			}
+		}
+		return false
+	})
+	return sorted
 }
 
-// Sorts students by a given component using sort.Slice
+// Sorts students by a given component, keeping tied students in sheet order so repeated
+// runs list them identically
 func sortByComponent(students []Student, getVal func(Student) float64) []Student {
 	sorted := append([]Student{}, students...)
-	sort.Slice(sorted, func(i, j int) bool {
+	sort.SliceStable(sorted, func(i, j int) bool {
 		return getVal(sorted[i]) > getVal(sorted[j])
 	})
 	return sorted
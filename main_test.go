@@ -0,0 +1,490 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestParseRow(t *testing.T) {
+	tests := []struct {
+		name        string
+		row         []string
+		valid       bool
+		want        Student
+		discrepancy bool
+	}{
+		{
+			name:  "valid",
+			row:   []string{"1", "1", "1001", "2024A7PS0001", "25", "60", "50", "25", "160", "90", "250"},
+			valid: true,
+			want:  Student{EmpID: "1001", Branch: "2024A7", Quiz: 25, MidSem: 60, LabTest: 50, WeeklyLabs: 25, Compre: 90, Total: 250},
+		},
+		{
+			name:  "padded cells",
+			row:   []string{"1", "1", " 1002 ", " 2024A3PS0002", "20.5", "55", "45", "20", "140.5", "80", "220.5 "},
+			valid: true,
+			want:  Student{EmpID: "1002", Branch: "2024A3", Quiz: 20.5, MidSem: 55, LabTest: 45, WeeklyLabs: 20, Compre: 80, Total: 220.5},
+		},
+		{
+			name:  "byte order marks",
+			row:   []string{"1", "1", "\uFEFF1003", "\uFEFF2024A7PS0003", "\uFEFF28", "70", "55", "28", "181", "100", "281\uFEFF"},
+			valid: true,
+			want:  Student{EmpID: "1003", Branch: "2024A7", Quiz: 28, MidSem: 70, LabTest: 55, WeeklyLabs: 28, Compre: 100, Total: 281},
+		},
+		{
+			name: "invalid branch",
+			row:  []string{"1", "1", "1006", "XXXXXXPS0006", "25", "60", "50", "25", "160", "90", "250"},
+		},
+		{
+			name:        "discrepant total",
+			row:         []string{"1", "1", "1004", "2024A3PS0004", "15", "40", "30", "15", "100", "60", "165"},
+			valid:       true,
+			want:        Student{EmpID: "1004", Branch: "2024A3", Quiz: 15, MidSem: 40, LabTest: 30, WeeklyLabs: 15, Compre: 60, Total: 165},
+			discrepancy: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState(t)
+			got, malformed, valid := parseRow(tt.row)
+			if valid != tt.valid {
+				t.Fatalf("valid = %v, want %v", valid, tt.valid)
+			}
+			if len(malformed) > 0 {
+				t.Errorf("malformed = %v, want none", malformed)
+			}
+			if !valid {
+				return
+			}
+			got.Blank = nil
+			if got.EmpID != tt.want.EmpID || got.Branch != tt.want.Branch || computedTotal(got) != computedTotal(tt.want) || got.Total != tt.want.Total {
+				t.Errorf("parseRow = %+v, want %+v", got, tt.want)
+			}
+			reported := slices.ContainsFunc(findingMessages(levelError), func(m string) bool {
+				return strings.Contains(m, "Discrepancy in total marks")
+			})
+			if reported != tt.discrepancy {
+				t.Errorf("discrepancy reported = %v, want %v", reported, tt.discrepancy)
+			}
+		})
+	}
+}
+
+func TestProcessReader(t *testing.T) {
+	results, err := processFixture(t,
+		fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90),
+		fixtureRow("1002", "2024A7PS0002", 20, 55, 45, 20, 80),
+		fixtureRow("1003", "XXXXXXPS0003", 28, 70, 55, 28, 100),
+		[]any{1, 1, "1004", "2024A3PS0004", 15, 40, 30, 15, 100, 60, 170},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := empIDs(results.Students), []string{"1001", "1002", "1004"}; !slices.Equal(got, want) {
+		t.Errorf("students = %v, want %v", got, want)
+	}
+	if results.SkippedBranch != 1 {
+		t.Errorf("SkippedBranch = %d, want 1", results.SkippedBranch)
+	}
+	if got := len(discrepantStudents(results.Students)); got != 1 {
+		t.Errorf("discrepant students = %d, want 1", got)
+	}
+	if results.BranchCounts["2024A7"] != 2 || results.BranchSums["2024A7"] != 470 {
+		t.Errorf("2024A7 aggregate = %d students, sum %.2f; want 2, 470", results.BranchCounts["2024A7"], results.BranchSums["2024A7"])
+	}
+}
+
+func TestProcessFileFixtures(t *testing.T) {
+	for _, path := range []string{"testdata/sample.xlsx", "testdata/sample.ods"} {
+		t.Run(path, func(t *testing.T) {
+			resetState(t)
+			results, err := processFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := empIDs(results.Students), []string{"1001", "1002", "1003", "1004", "1005", "1007"}; !slices.Equal(got, want) {
+				t.Errorf("students = %v, want %v", got, want)
+			}
+			if results.SkippedBranch != 1 {
+				t.Errorf("SkippedBranch = %d, want 1", results.SkippedBranch)
+			}
+			if got := empIDs(discrepantStudents(results.Students)); !slices.Equal(got, []string{"1004", "1005"}) {
+				t.Errorf("discrepant = %v, want [1004 1005]", got)
+			}
+		})
+	}
+}
+
+// Row 1002 has "NA" in place of its lab test mark
+var malformedRows = [][]any{
+	fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90),
+	{2, 1, "1002", "2024A7PS0002", 20, 55, "NA", 20, 140, 80, 220},
+}
+
+func TestProcessMalformedCell(t *testing.T) {
+	tests := []struct {
+		name     string
+		skip     bool
+		students []string
+		skipped  int
+	}{
+		{name: "reported", students: []string{"1001", "1002"}},
+		{name: "skip-malformed", skip: true, students: []string{"1001"}, skipped: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlag(t, skipMalformedFlag, tt.skip)
+			results, err := processFixture(t, malformedRows...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := empIDs(results.Students); !slices.Equal(got, tt.students) {
+				t.Errorf("students = %v, want %v", got, tt.students)
+			}
+			if results.SkippedMalformed != tt.skipped {
+				t.Errorf("SkippedMalformed = %d, want %d", results.SkippedMalformed, tt.skipped)
+			}
+			errs := findingMessages(levelError)
+			if !slices.ContainsFunc(errs, func(m string) bool { return strings.Contains(m, `value "NA"`) }) {
+				t.Errorf("error findings %q do not report the NA cell", errs)
+			}
+		})
+	}
+}
+
+func TestFixTotalsLeavesMalformedRows(t *testing.T) {
+	resetState(t)
+	rows := append([][]any{{3, 1, "1003", "2024A3PS0003", 28, 70, 55, 28, 181, 100, 290}}, malformedRows...)
+	in := writeFixture(t, rows...)
+	out := filepath.Join(t.TempDir(), "fixed.xlsx")
+	if err := fixTotals(io.Discard, in, out); err != nil {
+		t.Fatal(err)
+	}
+
+	resetState(t)
+	results, err := processFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	totals := make(map[string]float64)
+	for _, s := range results.Students {
+		totals[s.EmpID] = s.Total
+	}
+	if totals["1003"] != 281 {
+		t.Errorf("1003 total = %.2f, want corrected to 281", totals["1003"])
+	}
+	if totals["1002"] != 220 {
+		t.Errorf("1002 total = %.2f, want left at 220", totals["1002"])
+	}
+}
+
+func TestPrintResults(t *testing.T) {
+	results, err := processFixture(t,
+		fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90),
+		fixtureRow("1002", "2021A7PS0002", 20, 55, 45, 20, 80),
+		fixtureRow("1003", "2024A3PS0003", 28, 70, 55, 28, 100),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	printResults(&buf, results, columns)
+	out := buf.String()
+
+	want := []string{
+		"1. EmpID: 1003 - 281.00",
+		"Overall Average Marks: 250.33",
+		"Branch 2021A7 (CSE 2021) Average Marks: 220.00\nBranch 2024A3 (EEE 2024) Average Marks: 281.00\nBranch 2024A7 (CSE 2024) Average Marks: 250.00\n",
+	}
+	for _, w := range want {
+		if !strings.Contains(out, w) {
+			t.Errorf("report lacks %q:\n%s", w, out)
+		}
+	}
+}
+
+func TestFixTotalsUsesDataSheet(t *testing.T) {
+	resetState(t)
+	f := excelize.NewFile()
+	defer f.Close()
+	if _, err := f.NewSheet("Grades"); err != nil {
+		t.Fatal(err)
+	}
+	notes := []any{"Notes", "", "", "", "", "", "", "", "", "", 999}
+	if err := f.SetSheetRow("Sheet1", "A2", &notes); err != nil {
+		t.Fatal(err)
+	}
+	for i, row := range [][]any{fixtureHeader, {1, 1, "1001", "2024A7PS0001", 25, 60, 50, 25, 160, 90, 240}} {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow("Grades", cell, &row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	in := filepath.Join(t.TempDir(), "multi.xlsx")
+	if err := f.SaveAs(in); err != nil {
+		t.Fatal(err)
+	}
+
+	dataSheet = "Grades"
+	out := filepath.Join(t.TempDir(), "fixed.xlsx")
+	if err := fixTotals(io.Discard, in, out); err != nil {
+		t.Fatal(err)
+	}
+	fixed, err := excelize.OpenFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fixed.Close()
+	if got, _ := fixed.GetCellValue("Grades", "K2"); got != "250" {
+		t.Errorf("Grades!K2 = %q, want the corrected 250", got)
+	}
+	if got, _ := fixed.GetCellValue("Sheet1", "K2"); got != "999" {
+		t.Errorf("Sheet1!K2 = %q, want it untouched", got)
+	}
+}
+
+func TestProcessNoHeader(t *testing.T) {
+	compact := ColumnSpec{EmpID: 0, CampusID: 1, Quiz: 2, MidSem: 3, LabTest: 4, WeeklyLabs: 5, Compre: 6, Total: 7}
+	tests := []struct {
+		name     string
+		noHeader bool
+		layout   ColumnSpec
+		rows     [][]any
+		students []string
+	}{
+		{
+			name:     "first row skipped as header",
+			layout:   defaultColumnSpec,
+			rows:     [][]any{fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90), fixtureRow("1002", "2024A7PS0002", 20, 55, 45, 20, 80)},
+			students: []string{"1002"},
+		},
+		{
+			name:     "first row read with --no-header",
+			noHeader: true,
+			layout:   defaultColumnSpec,
+			rows:     [][]any{fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90), fixtureRow("1002", "2024A7PS0002", 20, 55, 45, 20, 80)},
+			students: []string{"1001", "1002"},
+		},
+		{
+			name:     "index-mapped columns",
+			noHeader: true,
+			layout:   compact,
+			rows:     [][]any{{"1001", "2024A7PS0001", 25, 60, 50, 25, 90, 250}, {"1002", "2024A3PS0002", 20, 55, 45, 20, 80, 220}},
+			students: []string{"1001", "1002"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState(t)
+			setFlag(t, noHeaderFlag, tt.noHeader)
+			columns = tt.layout
+			results, err := processReader(buildSheets(t, map[string][][]any{"Sheet1": tt.rows}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := empIDs(results.Students); !slices.Equal(got, tt.students) {
+				t.Errorf("students = %v, want %v", got, tt.students)
+			}
+			if errs := findingMessages(levelError); len(errs) > 0 {
+				t.Errorf("unexpected errors: %q", errs)
+			}
+		})
+	}
+}
+
+func TestCleanCell(t *testing.T) {
+	for cell, want := range map[string]string{
+		"25":              "25",
+		"  25.5\t":        "25.5",
+		"\uFEFF2024A7PS1": "2024A7PS1",
+		"\uFEFF 1001 \n":  "1001",
+		"\u00a030\u00a0":  "30",
+		"":                "",
+		"2024 A7":         "2024 A7",
+	} {
+		if got := cleanCell(cell); got != want {
+			t.Errorf("cleanCell(%q) = %q, want %q", cell, got, want)
+		}
+	}
+}
+
+func TestProcessPaddedCells(t *testing.T) {
+	results, err := processFixture(t,
+		[]any{1, 1, " 1001", "\uFEFF2024A7PS0001 ", " 25 ", "60\t", "\uFEFF50", "25", "160", " 90", "250 "},
+		fixtureRow("1002", "2024A7PS0002", 20, 55, 45, 20, 80))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := empIDs(results.Students); !slices.Equal(got, []string{"1001", "1002"}) {
+		t.Fatalf("students = %v, want [1001 1002]", got)
+	}
+	if s := results.Students[0]; s.Branch != "2024A7" || s.Quiz != 25 || s.LabTest != 50 || s.Total != 250 {
+		t.Errorf("padded row parsed as %+v", s)
+	}
+	if errs := findingMessages(levelError); len(errs) > 0 {
+		t.Errorf("padded cells reported: %q", errs)
+	}
+}
+
+func TestProcessNoStudents(t *testing.T) {
+	tests := []struct {
+		name     string
+		sheet    [][]any
+		where    string
+		excluded bool
+	}{
+		{name: "header only", sheet: [][]any{fixtureHeader}},
+		{name: "empty sheet"},
+		{name: "no valid rows", sheet: [][]any{fixtureHeader, fixtureRow("1006", "XXXXXXPS0006", 25, 60, 50, 25, 90)}},
+		{name: "all excluded by --where", sheet: [][]any{fixtureHeader, fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90)}, where: "total>280", excluded: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState(t)
+			if tt.where != "" {
+				pred, err := parseWhere(tt.where)
+				if err != nil {
+					t.Fatal(err)
+				}
+				studentFilter = pred
+				t.Cleanup(func() { studentFilter = nil })
+			}
+			_, err := processReader(buildSheets(t, map[string][][]any{"Sheet1": tt.sheet}))
+			if !errors.Is(err, errNoStudents) {
+				t.Fatalf("error = %v, want %v", err, errNoStudents)
+			}
+			if excluded := strings.Contains(err.Error(), "--where"); excluded != tt.excluded {
+				t.Errorf("error %q mentions --where: %v, want %v", err, excluded, tt.excluded)
+			}
+		})
+	}
+}
+
+func TestParseMark(t *testing.T) {
+	tests := []struct {
+		cell    string
+		value   float64
+		present bool
+		wantErr bool
+	}{
+		{cell: "45", value: 45, present: true},
+		{cell: "45.0", value: 45, present: true},
+		{cell: "45.50", value: 45.5, present: true},
+		{cell: " 7 ", value: 7, present: true},
+		{cell: "0", value: 0, present: true},
+		{cell: "1,045.5", value: 1045.5, present: true},
+		{cell: ""},
+		{cell: "   "},
+		{cell: "NA", present: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cell, func(t *testing.T) {
+			value, present, err := parseMark(tt.cell)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if present != tt.present || (!tt.wantErr && value != tt.value) {
+				t.Errorf("parseMark(%q) = %v, %v; want %v, %v", tt.cell, value, present, tt.value, tt.present)
+			}
+		})
+	}
+}
+
+func TestProcessMixedMarkColumn(t *testing.T) {
+	results, err := processFixture(t,
+		[]any{1, 1, "1001", "2024A7PS0001", 25, 60, 50, 25, 160, 90, 250},
+		[]any{2, 1, "1002", "2024A7PS0002", "20.0", 55, 45, 20, 140, 80, 220},
+		[]any{3, 1, "1003", "2024A7PS0003", "", 70, 55, 28, 153, 100, 253},
+		[]any{4, 1, "1004", "2024A7PS0004", "18", 40, 30, 15, 103, 60, 163})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var quiz []float64
+	var blank [][]string
+	for _, s := range results.Students {
+		quiz = append(quiz, s.Quiz)
+		blank = append(blank, s.Blank)
+	}
+	if want := []float64{25, 20, 0, 18}; !slices.Equal(quiz, want) {
+		t.Errorf("quiz marks = %v, want %v", quiz, want)
+	}
+	if !slices.EqualFunc(blank, [][]string{nil, nil, {"quiz"}, nil}, slices.Equal) {
+		t.Errorf("blank components = %v, want only 1003's quiz", blank)
+	}
+	if errs := findingMessages(levelError); len(errs) > 0 {
+		t.Errorf("mixed marks reported as errors: %q", errs)
+	}
+	if infos := findingMessages(levelInfo); !slices.Contains(infos, "Quiz (30) was blank for 1 student(s) and read as 0") {
+		t.Errorf("info findings = %q, want the blank quiz noted", infos)
+	}
+}
+
+// Measures processing a generated 50,000-row sheet read through the streaming row iterator
+func BenchmarkProcessRows(b *testing.B) {
+	resetState(b)
+	path := writeWorkbook(b, fixtureHeader, generatedRows(50000)...)
+	b.ReportAllocs()
+	for b.Loop() {
+		findings = nil
+		results, err := processFile(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if results.TotalCount != 50000 {
+			b.Fatalf("TotalCount = %d, want 50000", results.TotalCount)
+		}
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		sep     string
+		cell    string
+		want    float64
+		wantErr bool
+	}{
+		{sep: ".", cell: "83.5", want: 83.5},
+		{sep: ".", cell: "1,045", want: 1045},
+		{sep: ".", cell: "1,045.25", want: 1045.25},
+		{sep: ".", cell: "-12,345,678", want: -12345678},
+		{sep: ".", cell: "83,50", wantErr: true},
+		{sep: ".", cell: "1,04", wantErr: true},
+		{sep: ".", cell: "1045,000,", wantErr: true},
+		{sep: ".", cell: ",500", wantErr: true},
+		{sep: ".", cell: "1.045,5", wantErr: true},
+		{sep: ",", cell: "83,5", want: 83.5},
+		{sep: ",", cell: "1.045,25", want: 1045.25},
+		{sep: ",", cell: "83.50", wantErr: true},
+		{sep: ",", cell: "12", want: 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sep+" "+tt.cell, func(t *testing.T) {
+			setFlag(t, decimalSep, tt.sep)
+			got, err := parseNumber(tt.cell)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseNumber(%q) error = %v, want error %v", tt.cell, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseNumber(%q) = %v, want %v", tt.cell, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessBadDigitGrouping(t *testing.T) {
+	_, err := processFixture(t, []any{1, 1, "1001", "2024A7PS0001", "25", "60", "50", "25", "160", "83,50", "243.5"})
+	if err != nil && !errors.Is(err, errNoStudents) {
+		t.Fatal(err)
+	}
+	if errs := findingMessages(levelError); !slices.ContainsFunc(errs, func(m string) bool { return strings.Contains(m, `"83,50"`) }) {
+		t.Errorf("errors = %q, want the badly grouped compre mark reported", errs)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Reads a column manifest: one expected header per line, in order, ignoring lines starting
+// with #. A blank line stands for a blank header cell; blank lines at the end are dropped,
+// as are trailing blank cells of the header row.
+func readManifest(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var headers []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		headers = append(headers, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for len(headers) > 0 && headers[len(headers)-1] == "" {
+		headers = headers[:len(headers)-1]
+	}
+	if len(headers) == 0 {
+		return nil, fmt.Errorf("%s declares no columns", path)
+	}
+	return headers, nil
+}
+
+// Returns the cleaned header row of the first sheet
+func readHeader(filePath string) ([]string, error) {
+	var header []string
+	err := scanRows(filePath, func(i int, row []string) bool {
+		if isHeaderRow(i) {
+			header = cleanCells(row)
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errors.New("sheet has no header row")
+	}
+	for len(header) > 0 && header[len(header)-1] == "" {
+		header = header[:len(header)-1]
+	}
+	return header, nil
+}
+
+// A header told apart from others with the same name by its occurrence, counted from 1
+type headerOccurrence struct {
+	name string
+	n    int
+}
+
+// Describes the header, naming its occurrence when the name appears more than once
+func (h headerOccurrence) String() string {
+	if h.n > 1 {
+		return fmt.Sprintf("%q (occurrence %d)", h.name, h.n)
+	}
+	return fmt.Sprintf("%q", h.name)
+}
+
+// Returns the headers as occurrences, so repeated names are matched one to one, and the
+// column of each occurrence
+func headerOccurrences(headers []string) ([]headerOccurrence, map[headerOccurrence]int) {
+	seen := make(map[string]int)
+	occurrences := make([]headerOccurrence, len(headers))
+	at := make(map[headerOccurrence]int, len(headers))
+	for i, name := range headers {
+		seen[name]++
+		occurrences[i] = headerOccurrence{name, seen[name]}
+		at[occurrences[i]] = i
+	}
+	return occurrences, at
+}
+
+// Describes how a header row differs from the expected one: missing, extra and
+// reordered columns. A name repeated in either row is matched occurrence by occurrence.
+// Returns nil when they match exactly.
+func diffHeaders(expected, actual []string) []string {
+	expectedOrder, expectedAt := headerOccurrences(expected)
+	actualOrder, actualAt := headerOccurrences(actual)
+
+	var diffs []string
+	var expectedCommon, actualCommon []headerOccurrence
+	for i, h := range expectedOrder {
+		if _, ok := actualAt[h]; ok {
+			expectedCommon = append(expectedCommon, h)
+		} else {
+			diffs = append(diffs, fmt.Sprintf("missing column %s (expected in column %s)", h, columnName(i)))
+		}
+	}
+	for i, h := range actualOrder {
+		if _, ok := expectedAt[h]; ok {
+			actualCommon = append(actualCommon, h)
+		} else {
+			diffs = append(diffs, fmt.Sprintf("extra column %s in column %s", h, columnName(i)))
+		}
+	}
+	// Compare the order of the columns present in both, so a missing or extra column
+	// does not make every later column look moved. Both hold the same occurrences.
+	for i, h := range expectedCommon {
+		if i < len(actualCommon) && actualCommon[i] != h {
+			diffs = append(diffs, fmt.Sprintf("column %s is out of order: found in column %s, expected in column %s", h, columnName(actualAt[h]), columnName(expectedAt[h])))
+		}
+	}
+	return diffs
+}
+
+// Returns the spreadsheet letter of a zero-based column index
+func columnName(i int) string {
+	name, err := excelize.ColumnNumberToName(i + 1)
+	if err != nil {
+		return fmt.Sprint(i + 1)
+	}
+	return name
+}
+
+// Checks that the sheet's header row matches the manifest exactly
+func validateSchema(filePath, manifestPath string) error {
+	expected, err := readManifest(manifestPath)
+	if err != nil {
+		return fmt.Errorf("read manifest: %w", err)
+	}
+	actual, err := readHeader(filePath)
+	if err != nil {
+		return err
+	}
+	if diffs := diffHeaders(expected, actual); len(diffs) > 0 {
+		return fmt.Errorf("header row does not match %s:\n  %s", manifestPath, strings.Join(diffs, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDiffHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected []string
+		actual   []string
+		want     []string
+	}{
+		{name: "match", expected: []string{"X", "Compre", "Total"}, actual: []string{"X", "Compre", "Total"}},
+		{
+			name:     "missing and extra",
+			expected: []string{"X", "Quiz", "Total"},
+			actual:   []string{"X", "Total", "Notes"},
+			want:     []string{`missing column "Quiz" (expected in column B)`, `extra column "Notes" in column C`},
+		},
+		{
+			name:     "reordered",
+			expected: []string{"X", "Quiz", "Total"},
+			actual:   []string{"X", "Total", "Quiz"},
+			want: []string{
+				`column "Quiz" is out of order: found in column C, expected in column B`,
+				`column "Total" is out of order: found in column B, expected in column C`,
+			},
+		},
+		{
+			name:     "duplicate missing from the sheet",
+			expected: []string{"X", "Compre", "Compre"},
+			actual:   []string{"X", "Compre"},
+			want:     []string{`missing column "Compre" (occurrence 2) (expected in column C)`},
+		},
+		{
+			name:     "duplicate added by the sheet",
+			expected: []string{"X", "Compre"},
+			actual:   []string{"Compre", "X", "Compre"},
+			want: []string{
+				`extra column "Compre" (occurrence 2) in column C`,
+				`column "X" is out of order: found in column B, expected in column A`,
+				`column "Compre" is out of order: found in column A, expected in column B`,
+			},
+		},
+		{name: "matching duplicates", expected: []string{"Compre", "X", "Compre"}, actual: []string{"Compre", "X", "Compre"}},
+		{name: "blank header", expected: []string{"X", "", "Total"}, actual: []string{"X", "", "Total"}},
+		{
+			name:     "blank header filled in",
+			expected: []string{"X", "", "Total"},
+			actual:   []string{"X", "Notes", "Total"},
+			want:     []string{`missing column "" (expected in column B)`, `extra column "Notes" in column B`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffHeaders(tt.expected, tt.actual); !slices.Equal(got, tt.want) {
+				t.Errorf("diffHeaders = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSchemaBlankHeader(t *testing.T) {
+	resetState(t)
+	header := []any{"S.No", "Class No", "Emp ID", "", "Quiz"}
+	sheet := writeWorkbook(t, header, fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90))
+	manifest := filepath.Join(t.TempDir(), "columns.txt")
+	if err := os.WriteFile(manifest, []byte("# grade sheet\nS.No\nClass No\nEmp ID\n\nQuiz\n\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	headers, err := readManifest(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"S.No", "Class No", "Emp ID", "", "Quiz"}; !slices.Equal(headers, want) {
+		t.Errorf("manifest = %q, want %q", headers, want)
+	}
+	if err := validateSchema(sheet, manifest); err != nil {
+		t.Errorf("validateSchema: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Prints the top lists and branch averages as GitHub-flavored Markdown tables
+func printMarkdown(w io.Writer, results Results) {
+	summary := buildSummary(results)
+	fmt.Fprintln(w, "## "+topSectionTitle(len(results.Students)))
+	for _, comp := range reportComponents {
+		fmt.Fprintf(w, "\n### %s\n\n", markdownEscape(comp.name()))
+		var rows [][]string
+		for i, entry := range summary.TopStudents[comp.name()] {
+			rows = append(rows, []string{fmt.Sprint(i + 1), entry.EmpID, fmt.Sprintf("%.2f", entry.Value)})
+		}
+		printMarkdownTable(w, []string{"Rank", idLabel, "Marks"}, rows, []bool{true, false, true})
+	}
+
+	fmt.Fprintln(w, "\n## Overall and Branch-Wise Averages")
+	fmt.Fprintln(w)
+	rows := [][]string{{"Overall", "", fmt.Sprint(results.TotalCount), formatAverage(summary.OverallAverage)}}
+	for _, b := range summary.BranchAverages {
+		rows = append(rows, []string{b.BranchCode, b.BranchName, fmt.Sprint(b.Count), formatAverage(b.Average)})
+	}
+	printMarkdownTable(w, []string{"Branch", "Name", "Students", "Average"}, rows, []bool{false, false, true, true})
+
+	fmt.Fprintf(w, "\n_Generated %s_\n", summary.GeneratedAt)
+}
+
+// Prints a Markdown table with every column padded to its widest cell; numeric columns
+// are right-aligned
+func printMarkdownTable(w io.Writer, header []string, rows [][]string, rightAlign []bool) {
+	cells := append([][]string{header}, rows...)
+	widths := make([]int, len(header))
+	for _, row := range cells {
+		for i := range row {
+			row[i] = markdownEscape(row[i])
+			widths[i] = max(widths[i], len(row[i]), 3)
+		}
+	}
+
+	line := func(row []string) {
+		padded := make([]string, len(row))
+		for i, cell := range row {
+			if rightAlign[i] {
+				padded[i] = fmt.Sprintf("%*s", widths[i], cell)
+			} else {
+				padded[i] = fmt.Sprintf("%-*s", widths[i], cell)
+			}
+		}
+		fmt.Fprintln(w, "| "+strings.Join(padded, " | ")+" |")
+	}
+
+	line(cells[0])
+	separators := make([]string, len(header))
+	for i, width := range widths {
+		if rightAlign[i] {
+			separators[i] = strings.Repeat("-", width-1) + ":"
+		} else {
+			separators[i] = strings.Repeat("-", width)
+		}
+	}
+	fmt.Fprintln(w, "| "+strings.Join(separators, " | ")+" |")
+	for _, row := range cells[1:] {
+		line(row)
+	}
+}
+
+// Escapes characters that would break a Markdown table cell
+func markdownEscape(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus collectors exposed on /metrics in server mode
+var (
+	requestsHandled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grades_http_requests_total",
+		Help: "HTTP requests handled, by route pattern and status code.",
+	}, []string{"route", "code"})
+	filesProcessed = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "grades_files_processed_total",
+		Help: "Workbooks processed.",
+	})
+	rowsParsed = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "grades_rows_parsed_total",
+		Help: "Data rows read from processed workbooks.",
+	})
+	parseErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "grades_parse_errors_total",
+		Help: "Data rows skipped because they could not be parsed.",
+	})
+	processingSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "grades_processing_seconds",
+		Help:    "Time taken to process one workbook.",
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 8),
+	})
+)
+
+func init() {
+	prometheus.MustRegister(requestsHandled, filesProcessed, rowsParsed, parseErrors, processingSeconds)
+}
+
+// Updates the processing metrics for one workbook that took the given time
+func observeProcessing(results Results, elapsed time.Duration) {
+	filesProcessed.Inc()
+	rowsParsed.Add(float64(results.TotalCount + results.Filtered + results.Skipped()))
+	parseErrors.Add(float64(results.Skipped()))
+	processingSeconds.Observe(elapsed.Seconds())
+}
+
+// Records the status code a handler writes
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Counts every request served by next by its matched route pattern and status code
+func countRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		requestsHandled.WithLabelValues(route, strconv.Itoa(rec.code)).Inc()
+	})
+}
+
+// Returns the handler serving the registered collectors in the Prometheus text format
+func metricsHandler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// One table of an OpenDocument spreadsheet
+type odsSheet struct {
+	name   string
+	rows   [][]string
+	merges []mergedRange
+}
+
+// Reports whether path names an OpenDocument spreadsheet
+func isODS(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".ods")
+}
+
+// Reads every table of a local .ods file. Repeated empty rows and cells are expanded only
+// when followed by content, so the sheet-wide padding LibreOffice writes is dropped.
+func readODS(path string) ([]odsSheet, error) {
+	if isURL(path) {
+		return nil, fmt.Errorf("remote .ods files are not supported, download it or export it as .xlsx")
+	}
+	z, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	defer z.Close()
+
+	for _, file := range z.File {
+		if file.Name != "content.xml" {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return parseODSContent(rc)
+	}
+	return nil, fmt.Errorf("%s has no content.xml; is it an OpenDocument spreadsheet?", path)
+}
+
+// Parses the tables in an OpenDocument content.xml stream
+func parseODSContent(r io.Reader) ([]odsSheet, error) {
+	var sheets []odsSheet
+	var row []string
+	var cell strings.Builder
+	var cellValue string
+	cellRepeat, rowRepeat := 1, 1
+	colSpan, rowSpan := 1, 1
+	pendingCells, pendingRows := 0, 0
+	inCell, paragraphs := false, 0
+
+	repeatAttr := func(el xml.StartElement, name string) int {
+		for _, a := range el.Attr {
+			if a.Name.Local == name {
+				if n, err := strconv.Atoi(a.Value); err == nil && n > 0 {
+					return n
+				}
+			}
+		}
+		return 1
+	}
+
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return sheets, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse content.xml: %w", err)
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "table":
+				name := ""
+				for _, a := range el.Attr {
+					if a.Name.Local == "name" {
+						name = a.Value
+					}
+				}
+				sheets = append(sheets, odsSheet{name: name})
+				pendingRows = 0
+			case "table-row":
+				row = nil
+				pendingCells = 0
+				rowRepeat = repeatAttr(el, "number-rows-repeated")
+			case "table-cell", "covered-table-cell":
+				inCell, paragraphs = true, 0
+				cell.Reset()
+				cellValue = ""
+				cellRepeat = repeatAttr(el, "number-columns-repeated")
+				colSpan = repeatAttr(el, "number-columns-spanned")
+				rowSpan = repeatAttr(el, "number-rows-spanned")
+				for _, a := range el.Attr {
+					if a.Name.Local == "value" {
+						cellValue = a.Value
+					}
+				}
+			case "p":
+				if inCell && paragraphs > 0 {
+					cell.WriteString("\n")
+				}
+				paragraphs++
+			}
+		case xml.CharData:
+			if inCell {
+				cell.Write(el)
+			}
+		case xml.EndElement:
+			switch el.Name.Local {
+			case "table-cell", "covered-table-cell":
+				inCell = false
+				value := cellValue
+				if value == "" {
+					value = cell.String()
+				}
+				// A merged cell is followed by covered cells standing in for the rest of it
+				if (colSpan > 1 || rowSpan > 1) && len(sheets) > 0 {
+					sheet := &sheets[len(sheets)-1]
+					startCol, startRow := len(row)+pendingCells+1, len(sheet.rows)+pendingRows+1
+					sheet.merges = append(sheet.merges, mergedRange{startCol, startRow, startCol + colSpan - 1, startRow + rowSpan - 1, value})
+				}
+				colSpan, rowSpan = 1, 1
+				if value == "" {
+					pendingCells += cellRepeat
+					continue
+				}
+				for ; pendingCells > 0; pendingCells-- {
+					row = append(row, "")
+				}
+				for n := 0; n < cellRepeat; n++ {
+					row = append(row, value)
+				}
+			case "table-row":
+				if len(sheets) == 0 {
+					continue
+				}
+				sheet := &sheets[len(sheets)-1]
+				if len(row) == 0 {
+					pendingRows += rowRepeat
+					continue
+				}
+				for ; pendingRows > 0; pendingRows-- {
+					sheet.rows = append(sheet.rows, nil)
+				}
+				for n := 0; n < rowRepeat; n++ {
+					sheet.rows = append(sheet.rows, row)
+				}
+			}
+		}
+	}
+}
+
+// Streams the rows of the first table, or dataSheet, of an .ods file to fn like scanRows does for .xlsx,
+// applying the Branches table, --range and the header handling
+func scanODSRows(filePath string, fn func(i int, row []string) bool) error {
+	sheets, err := readODS(filePath)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+	if len(sheets) == 0 {
+		return fmt.Errorf("read rows: %s has no tables", filePath)
+	}
+	data := sheets[0]
+	for _, sheet := range sheets {
+		if sheet.name == branchSheetName {
+			applyBranchRows(sheet.rows)
+		}
+		if dataSheet != "" && sheet.name == dataSheet {
+			data = sheet
+		}
+	}
+
+	_, originRow := rangeOrigin()
+	for r, row := range data.rows {
+		if dataRange != nil && r >= dataRange.toRow {
+			break
+		}
+		if !rowInRange(r) {
+			continue
+		}
+		i := r - (originRow - 1)
+		if isHeaderRow(i) {
+			row = fillMerges(slices.Clone(row), r, data.merges)
+		}
+		if !fn(i, clipRow(row)) {
+			return nil
+		}
+	}
+	return nil
+}
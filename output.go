@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Discards the --out report being written; a no-op unless one is open
+var abortReport = func() {}
+
+// Runs print against stdout, or with a path against a temporary file that replaces path
+// only once the whole report is written, so a failed run never leaves a truncated report
+func writeReport(path string, print func(w io.Writer) error) error {
+	if path == "" {
+		return print(os.Stdout)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	abortReport = func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+	defer func() { abortReport = func() {} }()
+
+	w := bufio.NewWriter(f)
+	err = print(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Chmod(0o644)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.txt")
+	if err := writeReport(path, func(w io.Writer) error {
+		_, err := fmt.Fprint(w, "first")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "first" {
+		t.Errorf("report = %q, want %q", got, "first")
+	}
+
+	// A report interrupted by a fatal error must leave the previous file in place
+	errAborted := errors.New("aborted")
+	func() {
+		defer func() {
+			if r := recover(); r != errAborted {
+				t.Fatalf("recovered %v", r)
+			}
+		}()
+		writeReport(path, func(w io.Writer) error {
+			fmt.Fprint(w, "partial")
+			abortReport()
+			panic(errAborted)
+		})
+	}()
+
+	// So must a report whose printer fails
+	if err := writeReport(path, func(w io.Writer) error {
+		fmt.Fprint(w, "partial")
+		return errAborted
+	}); err != errAborted {
+		t.Errorf("err = %v, want %v", err, errAborted)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "first" {
+		t.Errorf("report after abort = %q, want %q", got, "first")
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("directory holds %d files, want only the report", len(entries))
+	}
+}
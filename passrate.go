@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// Bisection steps taken by --target-pass-rate, enough to pin the value well below 0.01
+const passRateIterations = 60
+
+// Returns the fraction of students passing when each Total is rebuilt from the pre-compre
+// marks and an adjusted compre. "weight" scales compre by value; "offset" adds value to
+// compre, capped at its maximum.
+func passRateWith(students []Student, by string, value float64) float64 {
+	compre, _ := componentByKey("compre")
+	passed := 0
+	for _, s := range students {
+		adjusted := s.Compre * value
+		if by == "offset" {
+			adjusted = math.Min(s.Compre+value, compre.max)
+		}
+		if preCompreTotal(s)+adjusted >= passThreshold(s.Branch) {
+			passed++
+		}
+	}
+	return float64(passed) / float64(len(students))
+}
+
+// Finds by bisection the smallest compre weight or offset at which at least target of the
+// students pass. The pass rate never falls as the value grows, so the search is bounded
+// by zero and the value at which compre alone could cover the whole Total. Reports false
+// when even the upper bound falls short.
+func solvePassRate(students []Student, by string, target float64) (float64, bool) {
+	compre, _ := componentByKey("compre")
+	total, _ := componentByKey("total")
+	lo, hi := 0.0, total.max/compre.max
+	if by == "offset" {
+		hi = compre.max
+	}
+	if passRateWith(students, by, lo) >= target {
+		return lo, true
+	}
+	if passRateWith(students, by, hi) < target {
+		return hi, false
+	}
+	for range passRateIterations {
+		mid := (lo + hi) / 2
+		if passRateWith(students, by, mid) >= target {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi, true
+}
+
+// Prints the compre weight or offset that yields the target pass rate
+func printTargetPassRate(w io.Writer, students []Student, by string, target float64) {
+	if !passEnabled() {
+		fatalf("--target-pass-rate requires --pass or --branch-pass")
+	}
+
+	fmt.Fprintln(w, bold("======================================"))
+	fmt.Fprintln(w, bold(fmt.Sprintf("Compre %s for a %.2f%% Pass Rate", by, 100*target)))
+	weight := 1.0
+	if by == "offset" {
+		weight = 0
+	}
+	fmt.Fprintf(w, "Current pass rate: %.2f%%\n", 100*passRateWith(students, by, weight))
+
+	value, ok := solvePassRate(students, by, target)
+	if !ok {
+		fmt.Fprintf(w, "Not achievable: a compre %s of %.2f passes only %.2f%%\n", by, value, 100*passRateWith(students, by, value))
+		return
+	}
+	rate := 100 * passRateWith(students, by, value)
+	if by == "offset" {
+		fmt.Fprintf(w, "Adding %.2f marks to compre (capped at its maximum) gives a %.2f%% pass rate\n", value, rate)
+		return
+	}
+	fmt.Fprintf(w, "Weighting compre by %.4f gives a %.2f%% pass rate\n", value, rate)
+}
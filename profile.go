@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// Stops the profiles started by startProfiles, writing the heap profile; a no-op until
+// profiling starts
+var stopProfiles = func() {}
+
+// Starts a CPU profile written to cpuPath and arranges for a heap profile to be written
+// to memPath when stopProfiles runs. Either path may be empty.
+func startProfiles(cpuPath, memPath string) error {
+	var cpu *os.File
+	if cpuPath != "" {
+		f, err := os.Create(cpuPath)
+		if err != nil {
+			return err
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return fmt.Errorf("start CPU profile: %w", err)
+		}
+		cpu = f
+	}
+
+	stopped := false
+	stopProfiles = func() {
+		if stopped {
+			return
+		}
+		stopped = true
+		if cpu != nil {
+			pprof.StopCPUProfile()
+			if err := cpu.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", cpuPath, err)
+			}
+		}
+		if memPath != "" {
+			if err := writeHeapProfile(memPath); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", memPath, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Writes a heap profile of the live objects after a garbage collection
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Flushes any running profiles and discards a partial --out report, then logs and exits
+// like log.Fatalf, so a failing run still leaves usable profiles behind
+func fatalf(format string, args ...any) {
+	stopProfiles()
+	abortReport()
+	log.Fatalf(format, args...)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	texttemplate "text/template"
+)
+
+// What a report card shows for one student
+type ReportCard struct {
+	IDLabel      string
+	EmpID        string
+	Branch       string
+	BranchName   string
+	Components   []ReportCardLine
+	Total        float64
+	Grade        string
+	OverallRank  int
+	OverallCount int
+	BranchRank   int
+	BranchCount  int
+	Percentile   float64
+}
+
+// One component on a report card
+type ReportCardLine struct {
+	Name       string
+	Value      float64
+	Percentile float64
+}
+
+const reportCardHTML = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Report card for {{.EmpID}}</title></head>
+<body>
+<h1>{{.IDLabel}} {{.EmpID}}</h1>
+<p>Branch {{.Branch}} ({{.BranchName}})</p>
+<table border="1" cellpadding="4">
+<tr><th>Component</th><th>Marks</th><th>Percentile</th></tr>
+{{range .Components}}<tr><td>{{.Name}}</td><td>{{printf "%.2f" .Value}}</td><td>{{printf "%.0f" .Percentile}}</td></tr>
+{{end}}<tr><th>Total</th><th>{{printf "%.2f" .Total}}</th><th>{{printf "%.0f" .Percentile}}</th></tr>
+</table>
+{{if .Grade}}<p>Grade: {{.Grade}}</p>{{end}}
+<p>Overall rank: {{.OverallRank}} of {{.OverallCount}}</p>
+<p>Branch rank: {{.BranchRank}} of {{.BranchCount}}</p>
+</body></html>
+`
+
+const reportCardText = `{{.IDLabel}} {{.EmpID}}
+Branch {{.Branch}} ({{.BranchName}})
+{{range .Components}}{{.Name}}: {{printf "%.2f" .Value}} (percentile {{printf "%.0f" .Percentile}})
+{{end}}Total: {{printf "%.2f" .Total}} (percentile {{printf "%.0f" .Percentile}})
+{{if .Grade}}Grade: {{.Grade}}
+{{end}}Overall rank: {{.OverallRank}} of {{.OverallCount}}
+Branch rank: {{.BranchRank}} of {{.BranchCount}}
+`
+
+// Characters replaced when an EmpID is used as a file name
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Writes one report card per student into dir, as "html" or "txt"
+func writeReportCards(dir, format string, students []Student) error {
+	render, err := reportCardRenderer(format)
+	if err != nil {
+		return err
+	}
+	names, err := reportCardNames(students, format)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	total, _ := componentByKey("total")
+	overall := overallRanks(students)
+	branchRanks := make(map[int]int)
+	groups := studentsByBranch(students)
+	for _, group := range groups {
+		for i, rank := range overallRanks(group) {
+			branchRanks[group[i].Row] = rank
+		}
+	}
+
+	for i, s := range students {
+		card := ReportCard{
+			IDLabel:      idLabel,
+			EmpID:        s.EmpID,
+			Branch:       s.Branch,
+			BranchName:   branchName(s.Branch),
+			Total:        s.Total,
+			OverallRank:  overall[i],
+			OverallCount: len(students),
+			BranchRank:   branchRanks[s.Row],
+			BranchCount:  len(groups[branchGroup(s.Branch)]),
+		}
+		card.Percentile = percentileRank(students, total, s)
+		for _, comp := range reportComponents {
+			if comp.key == "total" {
+				continue
+			}
+			card.Components = append(card.Components, ReportCardLine{Name: comp.name(), Value: comp.getVal(s), Percentile: percentileRank(students, comp, s)})
+		}
+		if len(gradeCutoffs) > 0 {
+			card.Grade = assignGrade(s.Total, gradeCutoffs)
+		}
+
+		name := names[i]
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		if err := render(f, card); err != nil {
+			f.Close()
+			return fmt.Errorf("render %s: %w", name, err)
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the file names of the students' report cards, aligned with students and derived
+// from each EmpID. Students whose names would clash, case-insensitively, once unsafe
+// characters are replaced get their sheet row appended, e.g. "A_1-row7.html".
+func reportCardNames(students []Student, format string) ([]string, error) {
+	bases := make([]string, len(students))
+	uses := make(map[string]int)
+	for i, s := range students {
+		base := unsafeFileChars.ReplaceAllString(strings.TrimSpace(s.EmpID), "_")
+		if strings.Trim(base, "._") == "" {
+			return nil, fmt.Errorf("row %d: %s %q cannot be used as a file name", s.Row, idLabel, s.EmpID)
+		}
+		bases[i] = base
+		uses[strings.ToLower(base)]++
+	}
+
+	names := make([]string, len(students))
+	taken := make(map[string]int)
+	for i, s := range students {
+		name := bases[i]
+		if uses[strings.ToLower(name)] > 1 {
+			name = fmt.Sprintf("%s-row%d", name, s.Row)
+		}
+		name += "." + format
+		if row, ok := taken[strings.ToLower(name)]; ok {
+			return nil, fmt.Errorf("rows %d and %d: report cards would both be named %s", row, s.Row, name)
+		}
+		taken[strings.ToLower(name)] = s.Row
+		names[i] = name
+	}
+	return names, nil
+}
+
+// Returns the function rendering a report card in the given format
+func reportCardRenderer(format string) (func(io.Writer, ReportCard) error, error) {
+	switch strings.ToLower(format) {
+	case "html":
+		tmpl := htmltemplate.Must(htmltemplate.New("card").Parse(reportCardHTML))
+		return func(w io.Writer, c ReportCard) error { return tmpl.Execute(w, c) }, nil
+	case "txt":
+		tmpl := texttemplate.Must(texttemplate.New("card").Parse(reportCardText))
+		return func(w io.Writer, c ReportCard) error { return tmpl.Execute(w, c) }, nil
+	}
+	return nil, fmt.Errorf("unknown report card format %q: must be \"html\" or \"txt\"", format)
+}
+
+// Returns the percentage of students scoring at or below s in a component
+func percentileRank(students []Student, comp component, s Student) float64 {
+	atOrBelow := 0
+	for _, other := range students {
+		if comp.getVal(other) <= comp.getVal(s) {
+			atOrBelow++
+		}
+	}
+	return 100 * float64(atOrBelow) / float64(len(students))
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReportCardNames(t *testing.T) {
+	students := []Student{
+		{EmpID: "1001", Row: 2},
+		{EmpID: "A/1", Row: 3},
+		{EmpID: "a:1", Row: 4},
+		{EmpID: "1002", Row: 5},
+	}
+	names, err := reportCardNames(students, "html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"1001.html", "A_1-row3.html", "a_1-row4.html", "1002.html"}
+	if !slices.Equal(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+}
+
+func TestReportCardNamesRejectsUnusableIDs(t *testing.T) {
+	for _, id := range []string{"", "  ", "..", "///"} {
+		_, err := reportCardNames([]Student{{EmpID: "1001", Row: 2}, {EmpID: id, Row: 3}}, "txt")
+		if err == nil || !strings.Contains(err.Error(), "row 3") {
+			t.Errorf("EmpID %q: error = %v, want one naming row 3", id, err)
+		}
+	}
+}
+
+func TestReportCardNamesRejectsRemainingClash(t *testing.T) {
+	students := []Student{{EmpID: "A/1", Row: 3}, {EmpID: "A:1", Row: 4}, {EmpID: "A_1-row3", Row: 9}}
+	if _, err := reportCardNames(students, "txt"); err == nil {
+		t.Error("clashing names accepted")
+	}
+}
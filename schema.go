@@ -0,0 +1,66 @@
+package main
+
+import (
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Prints the draft-07 JSON Schema describing the JSON encoding of v
+func printJSONSchema(w io.Writer, v any) error {
+	schema := schemaFor(reflect.TypeOf(v))
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = reflect.TypeOf(v).Name()
+	return printJSON(w, schema)
+}
+
+// Builds the schema for a Go type following encoding/json's marshaling rules
+func schemaFor(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := schemaFor(t.Elem())
+		if types, ok := schema["type"].([]any); ok {
+			if types[len(types)-1] != "null" {
+				schema["type"] = append(types, "null")
+			}
+		} else {
+			schema["type"] = []any{schema["type"], "null"}
+		}
+		return schema
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": []any{"array", "null"}, "items": schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(t.Elem())}
+	case reflect.Struct:
+		properties := make(map[string]any)
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = schemaFor(field.Type)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]any{"type": "object", "properties": properties, "required": required, "additionalProperties": false}
+	}
+	return map[string]any{}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 1000
+)
+
+// A page of results returned by the list endpoints
+type Page[T any] struct {
+	Total int `json:"total"`
+	Page  int `json:"page"`
+	Size  int `json:"size"`
+	Items []T `json:"items"`
+}
+
+// Serves the processed results over HTTP until the server fails. Uploads are read from
+// the base layout, adjusted for each upload as for any other input.
+func serve(addr string, results Results, base ColumnSpec) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /students", func(w http.ResponseWriter, r *http.Request) {
+		writeSortedPage(w, r, results.Students)
+	})
+	mux.HandleFunc("GET /branches/{code}/students", func(w http.ResponseWriter, r *http.Request) {
+		code := r.PathValue("code")
+		if _, exists := results.BranchCounts[code]; !exists {
+			writeError(w, http.StatusNotFound, "unknown branch "+code)
+			return
+		}
+		writeSortedPage(w, r, studentsByBranch(results.Students)[code])
+	})
+
+	mux.HandleFunc("GET /stats", func(w http.ResponseWriter, r *http.Request) {
+		writeStat(w, r, results)
+	})
+
+	baseline := &uploadBaseline{students: results.Students}
+	mux.HandleFunc("PUT /baseline", func(w http.ResponseWriter, r *http.Request) {
+		students, err := processUpload(w, r, base)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		baseline.set(students)
+		writeJSON(w, http.StatusOK, map[string]int{"students": len(students)})
+	})
+	mux.HandleFunc("POST /diff", func(w http.ResponseWriter, r *http.Request) {
+		students, err := processUpload(w, r, base)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, diffRuns(baseline.get(), students))
+	})
+
+	mux.Handle("GET /metrics", metricsHandler())
+
+	slog.Info(fmt.Sprintf("Serving %d students on %s", len(results.Students), addr), "students", len(results.Students), "addr", addr)
+	return http.ListenAndServe(addr, countRequests(mux))
+}
+
+// Largest workbook accepted by PUT /baseline and POST /diff
+const maxUploadSize = 32 << 20
+
+// Students that POST /diff compares uploads against: the served results until a
+// workbook is uploaded to PUT /baseline
+type uploadBaseline struct {
+	mu       sync.Mutex
+	students []Student
+}
+
+func (b *uploadBaseline) get() []Student {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.students
+}
+
+func (b *uploadBaseline) set(students []Student) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.students = students
+}
+
+// Serializes processing of uploads, since reading a workbook updates package state
+var uploadMu sync.Mutex
+
+// Processes a workbook sent as the request body; the name query parameter, e.g.
+// name=grades.ods, tells .ods uploads apart from the default .xlsx. The upload is spooled
+// to a temporary file so its layout is prepared from base like a file on disk, and the
+// findings, branch names and layout it brings are dropped once it is processed.
+func processUpload(w http.ResponseWriter, r *http.Request, base ColumnSpec) ([]Student, error) {
+	body := http.MaxBytesReader(w, r.Body, maxUploadSize)
+	pattern := "upload-*.xlsx"
+	if isODS(r.URL.Query().Get("name")) {
+		pattern = "upload-*.ods"
+	}
+	tmp, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read upload: %w", err)
+	}
+	return processUploaded(tmp.Name(), base)
+}
+
+// Prepares the layout of one uploaded file and processes it with the package state
+// restored afterwards, counting it in the processing metrics
+func processUploaded(filePath string, base ColumnSpec) ([]Student, error) {
+	uploadMu.Lock()
+	defer uploadMu.Unlock()
+	defer saveRunState().restore()
+
+	if err := prepareLayout(filePath, base); err != nil {
+		return nil, fmt.Errorf("read the layout: %w", err)
+	}
+	started := time.Now()
+	results, err := processFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	observeProcessing(results, time.Since(started))
+	return results.Students, nil
+}
+
+// Writes a page of students ordered by the sort and order query parameters, by default
+// Total descending. Ties are broken by EmpID so pages are stable across requests.
+func writeSortedPage(w http.ResponseWriter, r *http.Request, students []Student) {
+	key := r.URL.Query().Get("sort")
+	if key == "" {
+		key = "total"
+	}
+	comp, ok := componentByKey(key)
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown sort %q (valid: %s)", key, strings.Join(componentKeys(), ", ")))
+		return
+	}
+	order := r.URL.Query().Get("order")
+	if order == "" {
+		order = "desc"
+	}
+	if order != "asc" && order != "desc" {
+		writeError(w, http.StatusBadRequest, "order must be asc or desc")
+		return
+	}
+
+	sorted := append([]Student{}, students...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := comp.getVal(sorted[i]), comp.getVal(sorted[j])
+		if a != b {
+			return (a < b) == (order == "asc")
+		}
+		return sorted[i].EmpID < sorted[j].EmpID
+	})
+	writePage(w, r, sorted)
+}
+
+// Statistics available from GET /stats, by metric name
+var statMetrics = map[string]func([]float64) float64{
+	"mean":   mean,
+	"median": median,
+	"stddev": stddev,
+	"min":    slices.Min[[]float64],
+	"max":    slices.Max[[]float64],
+}
+
+// A single statistic returned by GET /stats
+type StatValue struct {
+	Branch    string  `json:"branch,omitempty"`
+	Component string  `json:"component"`
+	Metric    string  `json:"metric"`
+	Count     int     `json:"count"`
+	Value     float64 `json:"value"`
+}
+
+// Writes one statistic chosen by the metric, component and optional branch query parameters
+func writeStat(w http.ResponseWriter, r *http.Request, results Results) {
+	query := r.URL.Query()
+	metric := query.Get("metric")
+	if metric == "" {
+		metric = "mean"
+	}
+	compute, ok := statMetrics[metric]
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown metric %q (valid: %s)", metric, strings.Join(slices.Sorted(maps.Keys(statMetrics)), ", ")))
+		return
+	}
+	key := query.Get("component")
+	if key == "" {
+		key = "total"
+	}
+	comp, ok := componentByKey(key)
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown component %q (valid: %s)", key, strings.Join(componentKeys(), ", ")))
+		return
+	}
+
+	students := results.Students
+	branch := query.Get("branch")
+	if branch != "" {
+		if _, exists := results.BranchCounts[branch]; !exists {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown branch %q (valid: %s)", branch, strings.Join(sortedBranches(results.BranchCounts), ", ")))
+			return
+		}
+		students = studentsByBranch(students)[branch]
+	}
+	if len(students) == 0 {
+		writeError(w, http.StatusNotFound, "no students to compute statistics over")
+		return
+	}
+
+	values := make([]float64, len(students))
+	for i, s := range students {
+		values[i] = comp.getVal(s)
+	}
+	writeJSON(w, http.StatusOK, StatValue{
+		Branch:    branch,
+		Component: comp.key,
+		Metric:    metric,
+		Count:     len(values),
+		Value:     round2(compute(values)),
+	})
+}
+
+// Writes the page of items selected by the page and size query parameters
+func writePage[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	page, err := queryInt(r, "page", 1)
+	if err != nil || page < 1 {
+		writeError(w, http.StatusBadRequest, "page must be a positive integer")
+		return
+	}
+	size, err := queryInt(r, "size", defaultPageSize)
+	if err != nil || size < 1 {
+		writeError(w, http.StatusBadRequest, "size must be a positive integer")
+		return
+	}
+	size = min(size, maxPageSize)
+
+	start := min((page-1)*size, len(items))
+	end := min(start+size, len(items))
+	writeJSON(w, http.StatusOK, Page[T]{
+		Total: len(items),
+		Page:  page,
+		Size:  size,
+		Items: append([]T{}, items[start:end]...),
+	})
+}
+
+// Reads an integer query parameter, returning def when it is absent
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return def, nil
+	}
+	return strconv.Atoi(raw)
+}
+
+// Writes v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to write response", "error", err)
+	}
+}
+
+// Writes a JSON error body with the given status code
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
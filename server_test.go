@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestProcessUploadKeepsStateLocal(t *testing.T) {
+	resetState(t)
+	body := buildBranchWorkbook(t, map[string]string{"2024A7": "Uploaded CSE"}, fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90))
+
+	before, uploads := branchMap["2024A7"], counterValue(t, filesProcessed)
+	recordFinding(levelInfo, "before upload")
+	req := httptest.NewRequest("POST", "/diff", body)
+	students, err := processUpload(httptest.NewRecorder(), req, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(students) != 1 {
+		t.Fatalf("students = %d, want 1", len(students))
+	}
+	if got := counterValue(t, filesProcessed) - uploads; got != 1 {
+		t.Errorf("files processed metric grew by %g, want 1", got)
+	}
+	if got := branchMap["2024A7"]; got != before {
+		t.Errorf("branch name after upload = %q, want %q", got, before)
+	}
+	if len(findings) != 1 {
+		t.Errorf("findings after upload = %d, want only the one recorded before", len(findings))
+	}
+}
+
+// Returns the current value of a counter
+func counterValue(t *testing.T, c prometheus.Counter) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := c.Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+func TestProcessUploadPreparesItsOwnLayout(t *testing.T) {
+	resetState(t)
+	indexed := func(row []any) []any { return append([]any{"#"}, row...) }
+	body := buildSheets(t, map[string][][]any{"Sheet1": {
+		indexed(fixtureHeader),
+		indexed(fixtureRow("1001", "2024A7PS0001", 25, 60, 50, 25, 90)),
+	}})
+	layout := columns
+
+	req := httptest.NewRequest("POST", "/diff", body)
+	students, err := processUpload(httptest.NewRecorder(), req, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(students) != 1 || students[0].EmpID != "1001" {
+		t.Fatalf("students = %v, want 1001 read through the shifted layout", empIDs(students))
+	}
+	if columns.EmpID != layout.EmpID {
+		t.Errorf("EmpID column after upload = %d, want %d", columns.EmpID, layout.EmpID)
+	}
+}
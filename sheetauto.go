@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// A data sheet and how many of the expected column headers it has
+type sheetScore struct {
+	name     string
+	matched  int
+	expected int
+}
+
+// Header fields a grade sheet is expected to carry, each with its accepted spellings
+// and the layout column it locates
+var headerFields = []struct {
+	name   string
+	labels []string
+	field  func(*ColumnSpec) *int
+}{
+	{"EmpID", []string{"Emplid", "EmpID"}, func(c *ColumnSpec) *int { return &c.EmpID }},
+	{"Campus ID", []string{"Campus ID"}, func(c *ColumnSpec) *int { return &c.CampusID }},
+	{"Quiz", []string{"Quiz"}, func(c *ColumnSpec) *int { return &c.Quiz }},
+	{"Mid-Sem", []string{"Mid-Sem", "Mid Semester"}, func(c *ColumnSpec) *int { return &c.MidSem }},
+	{"Lab Test", []string{"Lab Test"}, func(c *ColumnSpec) *int { return &c.LabTest }},
+	{"Weekly Labs", []string{"Weekly Labs", "Weekly Lab"}, func(c *ColumnSpec) *int { return &c.WeeklyLabs }},
+	{"Compre", []string{"Compre", "Comprehensive"}, func(c *ColumnSpec) *int { return &c.Compre }},
+	{"Total", []string{"Total"}, func(c *ColumnSpec) *int { return &c.Total }},
+}
+
+// Returns the accepted spellings of each header field, in headerFields order, with the
+// identifier label read from the sheet accepted for EmpID
+func expectedHeaderLabels() [][]string {
+	labels := make([][]string, len(headerFields))
+	for i, hf := range headerFields {
+		labels[i] = hf.labels
+	}
+	labels[0] = append(slices.Clone(labels[0]), idLabel)
+	return labels
+}
+
+// Lowercases a header label and drops everything but letters and digits, so that
+// "Mid-Sem (75)" and "midsem" compare equal
+func headerKey(label string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, label)
+}
+
+// Returns the comparison key of every header cell, ignoring the maximum in parentheses
+func headerKeys(header []string) []string {
+	keys := make([]string, len(header))
+	for i, cell := range header {
+		cell, _, _ = strings.Cut(cell, "(")
+		keys[i] = headerKey(cell)
+	}
+	return keys
+}
+
+// Returns the index of the first key matching one of the spellings, or -1
+func findHeader(keys, spellings []string) int {
+	for i, key := range keys {
+		for _, spelling := range spellings {
+			if key != "" && key == headerKey(spelling) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Counts the expected header fields found in a header row
+func scoreHeader(header []string) int {
+	keys := headerKeys(header)
+	matched := 0
+	for _, spellings := range expectedHeaderLabels() {
+		if findHeader(keys, spellings) >= 0 {
+			matched++
+		}
+	}
+	return matched
+}
+
+// Scores the header of every data sheet and returns the best match, preferring the
+// earlier sheet on a tie. Fails when no sheet has any expected column.
+func bestDataSheet(filePath string) (sheetScore, error) {
+	names, err := dataSheetNames(filePath)
+	if err != nil {
+		return sheetScore{}, err
+	}
+	defer func() { dataSheet = "" }()
+
+	best := sheetScore{expected: len(expectedHeaderLabels())}
+	for _, name := range names {
+		dataSheet = name
+		header, err := readHeader(filePath)
+		if err != nil {
+			recordFinding(levelInfo, "Skipping sheet %s for --sheet-auto: %v", name, err)
+			continue
+		}
+		if matched := scoreHeader(header); matched > best.matched {
+			best.name, best.matched = name, matched
+		}
+	}
+	if best.name == "" {
+		return best, errors.New("no sheet has any of the expected column headers")
+	}
+	return best, nil
+}
+
+// Selects the best-matching sheet of filePath as the data sheet and reports the choice
+func selectDataSheet(filePath string) error {
+	best, err := bestDataSheet(filePath)
+	if err != nil {
+		return fmt.Errorf("--sheet-auto: %w", err)
+	}
+	dataSheet = best.name
+	recordFinding(levelInfo, "Using sheet %q, which matches %d of %d expected columns", best.name, best.matched, best.expected)
+	return nil
+}
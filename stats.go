@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Returns the population standard deviation of values
+func stddev(values []float64) float64 {
+	m := mean(values)
+	var sum float64
+	for _, v := range values {
+		sum += (v - m) * (v - m)
+	}
+	return math.Sqrt(sum / float64(len(values)))
+}
+
+// Returns the most frequent values after rounding to the nearest integer, in ascending
+// order; several values are returned when they tie for the highest frequency
+func modes(values []float64) ([]float64, int) {
+	counts := make(map[float64]int)
+	best := 0
+	for _, v := range values {
+		r := math.Round(v)
+		counts[r]++
+		best = max(best, counts[r])
+	}
+
+	var result []float64
+	for v, c := range counts {
+		if c == best {
+			result = append(result, v)
+		}
+	}
+	sort.Float64s(result)
+	return result, best
+}
+
+// Returns the mean of values after discarding the lowest and highest percent of them.
+// When too few values remain to discard any, the raw mean is returned with ok false.
+func trimmedMean(values []float64, percent float64) (float64, bool) {
+	k := int(float64(len(values)) * percent / 100)
+	if k == 0 || 2*k >= len(values) {
+		return mean(values), false
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	return mean(sorted[k : len(sorted)-k]), true
+}
+
+// Formats a trimmed mean to print beside the raw average
+func formatTrimmed(values []float64, percent float64) string {
+	trimmed, ok := trimmedMean(values, percent)
+	if !ok {
+		return fmt.Sprintf(" (trimmed %g%%: %.2f, too few students to trim so raw mean used)", percent, trimmed)
+	}
+	return fmt.Sprintf(" (trimmed %g%%: %.2f)", percent, trimmed)
+}
+
+// Returns the Total of every student
+func totalsOf(students []Student) []float64 {
+	totals := make([]float64, len(students))
+	for i, s := range students {
+		totals[i] = s.Total
+	}
+	return totals
+}
+
+// Mean and standard deviation of one component over all students
+type componentStat struct {
+	Mean   float64
+	StdDev float64
+}
+
+// Per-component statistics over the accepted students, keyed by component key
+var componentStats map[string]componentStat
+
+// Returns how many standard deviations a student's component value lies from the mean,
+// or 0 when every student has the same value
+func zScore(s Student, comp component) float64 {
+	stat := componentStats[comp.key]
+	if stat.StdDev == 0 {
+		return 0
+	}
+	return (comp.getVal(s) - stat.Mean) / stat.StdDev
+}
+
+// Returns the average of a student's z-scores over the components making up the Total
+func compositeZ(s Student) float64 {
+	var sum float64
+	n := 0
+	for _, comp := range components {
+		if comp.key == "total" {
+			continue
+		}
+		sum += zScore(s, comp)
+		n++
+	}
+	return sum / float64(n)
+}
+
+// Prints mean, median, standard deviation and mode of totals overall and per branch,
+// followed by the mean and standard deviation of each component
+func printStats(w io.Writer, students []Student) {
+	fmt.Fprintln(w, "\n"+bold("======================================"))
+	fmt.Fprintln(w, bold("Distribution Statistics (Total)"))
+
+	printDistribution(w, "Overall", totalsOf(students))
+	groups := studentsByBranch(students)
+	for _, branch := range sortedBranches(groups) {
+		printDistribution(w, fmt.Sprintf("Branch %s (%s)", branch, branchName(branch)), totalsOf(groups[branch]))
+	}
+
+	fmt.Fprintln(w)
+	for _, comp := range reportComponents {
+		stat := componentStats[comp.key]
+		fmt.Fprintf(w, "%s: Mean %.2f, Std Dev %.2f\n", comp.name(), stat.Mean, stat.StdDev)
+	}
+
+	fmt.Fprintln(w, "\n"+bold("======================================"))
+	fmt.Fprintln(w, bold("Range per Branch"))
+	for _, branch := range sortedBranches(groups) {
+		fmt.Fprintln(w, bold(fmt.Sprintf("Branch %s (%s)", branch, branchName(branch))))
+		for _, comp := range reportComponents {
+			low, high := extremes(groups[branch], comp)
+			fmt.Fprintf(w, "  %s: Min %.2f (%s), Max %.2f (%s)\n", comp.name(),
+				comp.getVal(low.student), low.holder(), comp.getVal(high.student), high.holder())
+		}
+	}
+}
+
+// The student holding an extreme value and how many others share it
+type extreme struct {
+	student Student
+	ties    int
+}
+
+// Describes who holds an extreme, noting how many students tie with them
+func (e extreme) holder() string {
+	if e.ties > 0 {
+		return fmt.Sprintf("%s %s, +%d tied", idLabel, e.student.EmpID, e.ties)
+	}
+	return fmt.Sprintf("%s %s", idLabel, e.student.EmpID)
+}
+
+// Returns the students with the lowest and highest value of comp. Among students tied on
+// an extreme, the one ordered first by comp and the --award-tiebreak components is chosen.
+func extremes(students []Student, comp component) (low, high extreme) {
+	sorted := sortByComposite(students, append([]component{comp}, awardTiebreak...))
+	maxValue, minValue := comp.getVal(sorted[0]), comp.getVal(sorted[len(sorted)-1])
+	high.student, high.ties = sorted[0], -1
+	low.ties = -1
+	for _, s := range sorted {
+		if comp.getVal(s) == maxValue {
+			high.ties++
+		}
+		if comp.getVal(s) == minValue {
+			if low.ties < 0 {
+				low.student = s
+			}
+			low.ties++
+		}
+	}
+	return low, high
+}
+
+// Prints the n students with the highest composite z-score
+func printZRanking(w io.Writer, students []Student, n int) {
+	fmt.Fprintln(w, "\n"+bold("======================================"))
+	fmt.Fprintln(w, bold("Top Students by Composite Z-Score"))
+
+	sorted := sortByComponent(students, compositeZ)
+	for i, s := range sorted[:min(n, len(sorted))] {
+		fmt.Fprintf(w, "%d. %s: %s, Composite Z: %.2f\n", i+1, idLabel, s.EmpID, compositeZ(s))
+	}
+}
+
+// Prints the distribution statistics of one group of totals on a single line
+func printDistribution(w io.Writer, label string, totals []float64) {
+	modeValues, count := modes(totals)
+	formatted := make([]string, len(modeValues))
+	for i, v := range modeValues {
+		formatted[i] = fmt.Sprintf("%.0f", v)
+	}
+	frequency := fmt.Sprintf("%d students", count)
+	if len(modeValues) > 1 {
+		frequency += " each"
+	}
+	fmt.Fprintf(w, "%s: Mean %.2f, Median %.2f, Std Dev %.2f, Mode %s (%s)\n",
+		label, mean(totals), median(totals), stddev(totals), strings.Join(formatted, ", "), frequency)
+}
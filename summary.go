@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// Machine-readable summary of a run, emitted by --format json
+type Summary struct {
+	TopStudents    map[string][]TopEntry `json:"topStudents"`
+	OverallAverage *float64              `json:"overallAverage"` // null when no student was accepted
+	BranchAverages []BranchAverage       `json:"branchAverages"`
+	Checksum       string                `json:"checksum"`
+	GeneratedAt    string                `json:"generatedAt"`
+
+	AverageGradePoint      *float64 `json:"averageGradePoint,omitempty"`
+	CreditWeightedAverage  *float64 `json:"creditWeightedAverage,omitempty"`
+	BranchesWithoutCredits []string `json:"branchesWithoutCredits,omitempty"` // left out of creditWeightedAverage
+
+	ProgramAverages []ProgramAverage `json:"programAverages,omitempty"` // set by --merge-years
+}
+
+// Average marks for one program across its admission years
+type ProgramAverage struct {
+	Program     string   `json:"program"`
+	Name        string   `json:"name"`
+	BranchCodes []string `json:"branchCodes"`
+	Average     *float64 `json:"average"`
+	Count       int      `json:"count"`
+}
+
+// A single entry in a component's top list
+type TopEntry struct {
+	EmpID string   `json:"empID"`
+	Value float64  `json:"value"`
+	Raw   *float64 `json:"raw,omitempty"` // the unweighted Total when Value is weighted by --weights
+}
+
+// Average marks for one branch
+type BranchAverage struct {
+	BranchCode string   `json:"branchCode"`
+	BranchName string   `json:"branchName"`
+	Average    *float64 `json:"average"`
+	Count      int      `json:"count"`
+	PassRate   *float64 `json:"passRate,omitempty"`
+	GradePoint *float64 `json:"gradePoint,omitempty"`
+}
+
+// Builds the JSON summary from the processed results
+func buildSummary(results Results) Summary {
+	summary := Summary{
+		TopStudents:    make(map[string][]TopEntry),
+		OverallAverage: finiteOrNil(round2(results.TotalSum / float64(results.TotalCount))),
+		Checksum:       reportHash(results),
+		GeneratedAt:    generatedAt(),
+	}
+
+	for _, comp := range reportComponents {
+		summary.TopStudents[comp.name()] = topEntries(results.Students, comp)
+	}
+
+	groups := studentsByBranch(results.Students)
+	if branchCredits != nil {
+		avg, missing := creditWeightedAverage(results)
+		summary.CreditWeightedAverage = finiteOrNil(round2(avg))
+		summary.BranchesWithoutCredits = missing
+	}
+	if gradePoints != nil {
+		summary.AverageGradePoint = finiteOrNil(round2(averageGradePoint(results.Students)))
+	}
+	for branch, sum := range results.BranchSums {
+		count := results.BranchCounts[branch]
+		avg := BranchAverage{
+			BranchCode: branch,
+			BranchName: branchName(branch),
+			Average:    finiteOrNil(round2(sum / float64(count))),
+			Count:      count,
+		}
+		if passEnabled() {
+			rate := round2(100 * float64(results.BranchPasses[branch]) / float64(count))
+			avg.PassRate = &rate
+		}
+		if gradePoints != nil {
+			avg.GradePoint = finiteOrNil(round2(averageGradePoint(groups[branch])))
+		}
+		summary.BranchAverages = append(summary.BranchAverages, avg)
+	}
+	sort.Slice(summary.BranchAverages, func(i, j int) bool {
+		return summary.BranchAverages[i].BranchCode < summary.BranchAverages[j].BranchCode
+	})
+	if *mergeYearsFlag {
+		for _, m := range mergeBranchYears(results.Students) {
+			summary.ProgramAverages = append(summary.ProgramAverages, ProgramAverage{
+				Program:     m.Program,
+				Name:        m.Name,
+				BranchCodes: m.Codes,
+				Average:     finiteOrNil(round2(m.Average)),
+				Count:       m.Count,
+			})
+		}
+	}
+
+	return summary
+}
+
+// Returns the best students in comp, ranked with the --award-tiebreak rules; with --weights
+// the Total list ranks and reports weighted totals
+func topEntries(students []Student, comp component) []TopEntry {
+	weighted := comp.key == "total" && componentWeights != nil
+	if weighted {
+		comp.getVal = weightedTotal
+	}
+	sorted := rankByComponent(students, comp)
+	entries := []TopEntry{}
+	for _, s := range sorted[:min(componentTopCount(len(sorted)), len(sorted))] {
+		entry := TopEntry{EmpID: s.EmpID, Value: round2(comp.getVal(s))}
+		if weighted {
+			raw := round2(s.Total)
+			entry.Raw = &raw
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Formats an optional average for the text report, as "n/a" when absent
+func formatAverage(v *float64) string {
+	if v == nil {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.2f", *v)
+}
+
+// Writes the summary as CSV rows tagged by section: the top students of each component,
+// the overall average, then each branch average, with marks at two decimals
+func writeSummaryCSV(out io.Writer, summary Summary) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{"section", "component", "rank", "emp_id", "branch_code", "branch_name", "value", "count"})
+	for _, comp := range reportComponents {
+		for i, entry := range summary.TopStudents[comp.name()] {
+			w.Write([]string{"top", comp.name(), strconv.Itoa(i + 1), entry.EmpID, "", "", formatDumpValue(entry.Value), ""})
+		}
+	}
+	w.Write([]string{"overall", "", "", "", "", "", formatOptional(summary.OverallAverage), ""})
+	for _, b := range summary.BranchAverages {
+		w.Write([]string{"branch", "", "", "", b.BranchCode, b.BranchName, formatOptional(b.Average), strconv.Itoa(b.Count)})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// Formats an optional mark for CSV, leaving it blank when absent
+func formatOptional(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return formatDumpValue(*v)
+}
+
+// Marshals a value compactly, or indented by two spaces after prefix with --json-pretty
+func marshalJSON(v any, prefix string) ([]byte, error) {
+	if *jsonPrettyFlag {
+		return json.MarshalIndent(v, prefix, "  ")
+	}
+	return json.Marshal(v)
+}
+
+// Writes a value to w as JSON
+func printJSON(w io.Writer, v any) error {
+	data, err := marshalJSON(v, "")
+	if err != nil {
+		return fmt.Errorf("write JSON: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write JSON: %w", err)
+	}
+	return nil
+}
+
+// Writes a value to a file as JSON
+func writeJSONFile(path string, v any) error {
+	data, err := marshalJSON(v, "")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Returns a pointer to v, or nil when v is NaN or infinite, which JSON cannot encode
+func finiteOrNil(v float64) *float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return &v
+}
+
+// Rounds to two decimal places to match the text report's precision
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// A student as emitted by --format json-full
+type StudentRecord struct {
+	Student
+	BranchName string             `json:"branchName"`
+	Rank       int                `json:"rank"`
+	Grade      string             `json:"grade,omitempty"`
+	Components map[string]float64 `json:"components"` // marks per reported component, keyed by dump column name
+}
+
+// Returns each student's overall rank by Total, aligned with the students slice.
+// Students tied on Total and every --award-tiebreak component share a rank.
+func overallRanks(students []Student) []int {
+	total, _ := componentByKey("total")
+	return ranksBy(students, total)
+}
+
+// Returns each student's rank by comp, aligned with the students slice, breaking ties
+// with the --award-tiebreak components like overallRanks does
+func ranksBy(students []Student, comp component) []int {
+	keys := append([]component{comp}, awardTiebreak...)
+
+	order := make([]int, len(students))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		for _, comp := range keys {
+			va, vb := comp.getVal(students[order[a]]), comp.getVal(students[order[b]])
+			if va != vb {
+				return va > vb
+			}
+		}
+		return false
+	})
+
+	ranks := make([]int, len(students))
+	for pos, idx := range order {
+		ranks[idx] = pos + 1
+		if pos > 0 && tiedOn(keys, students[idx], students[order[pos-1]]) {
+			ranks[idx] = ranks[order[pos-1]]
+		}
+	}
+	return ranks
+}
+
+// Reports whether two students have equal values for every key
+func tiedOn(keys []component, a, b Student) bool {
+	for _, comp := range keys {
+		if comp.getVal(a) != comp.getVal(b) {
+			return false
+		}
+	}
+	return true
+}
+
+// Writes the summary followed by every student record, encoding students one at a time
+// so large cohorts are never held as a single marshaled document
+func printFullJSON(out io.Writer, results Results) error {
+	w := bufio.NewWriter(out)
+	head, sep, tail := `{"summary":`, `,"students":[`, "]}"
+	recordPrefix, recordSep := "", "\n,"
+	if *jsonPrettyFlag {
+		head, sep, tail = "{\n  \"summary\": ", ",\n  \"students\": [\n    ", "\n  ]\n}"
+		recordPrefix, recordSep = "    ", ",\n    "
+	}
+
+	fmt.Fprint(w, head)
+	summary, err := marshalJSON(buildSummary(results), "  ")
+	if err != nil {
+		return fmt.Errorf("write JSON: %w", err)
+	}
+	w.Write(summary)
+	fmt.Fprint(w, sep)
+
+	ranks := overallRanks(results.Students)
+	for i, s := range results.Students {
+		if i > 0 {
+			fmt.Fprint(w, recordSep)
+		}
+		record := StudentRecord{Student: s, BranchName: branchName(s.Branch), Rank: ranks[i], Components: make(map[string]float64, len(components))}
+		for _, comp := range components {
+			record.Components[componentField(comp)] = comp.getVal(s)
+		}
+		if len(gradeCutoffs) > 0 {
+			record.Grade = assignGrade(s.Total, gradeCutoffs)
+		}
+		data, err := marshalJSON(record, recordPrefix)
+		if err != nil {
+			return fmt.Errorf("write JSON: %w", err)
+		}
+		w.Write(data)
+	}
+	if !*jsonPrettyFlag && len(results.Students) > 0 {
+		fmt.Fprint(w, "\n")
+	}
+	fmt.Fprintln(w, tail)
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("write JSON: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Number of pre-filled data rows in the grade-entry template
+const templateRows = 200
+
+// Zero-based column of the Pre-Compre subtotal in the standard layout
+const preCompreColumn = 8
+
+// Writes an empty grade sheet in the standard layout: headers labelled with each
+// component's maximum, Pre-Compre and Total formulas, and validation keeping every
+// component cell between 0 and its maximum
+func writeTemplate(path string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := f.GetSheetName(0)
+	layout := defaultColumnSpec
+
+	colName := func(idx int) string {
+		name, _ := excelize.ColumnNumberToName(idx + 1)
+		return name
+	}
+	cell := func(idx, row int) string {
+		name, _ := excelize.CoordinatesToCellName(idx+1, row)
+		return name
+	}
+
+	quiz, _ := componentByKey("quiz")
+	midSem, _ := componentByKey("midsem")
+	labTest, _ := componentByKey("labtest")
+	weekly, _ := componentByKey("weekly")
+	compre, _ := componentByKey("compre")
+	total, _ := componentByKey("total")
+	preCompreMax := quiz.max + midSem.max + labTest.max + weekly.max
+
+	headers := map[int]string{
+		0:                 "Sl",
+		1:                 "Class No",
+		layout.EmpID:      "Emplid",
+		layout.CampusID:   "Campus ID",
+		layout.Quiz:       quiz.name(),
+		layout.MidSem:     midSem.name(),
+		layout.LabTest:    labTest.name(),
+		layout.WeeklyLabs: weekly.name(),
+		preCompreColumn:   fmt.Sprintf("Pre-Compre (%g)", preCompreMax),
+		layout.Compre:     compre.name(),
+		layout.Total:      total.name(),
+	}
+	for idx, label := range headers {
+		if err := f.SetCellStr(sheet, cell(idx, 1), label); err != nil {
+			return err
+		}
+	}
+
+	for row := 2; row <= templateRows+1; row++ {
+		preCompre := fmt.Sprintf("SUM(%s:%s)", cell(layout.Quiz, row), cell(layout.WeeklyLabs, row))
+		if err := f.SetCellFormula(sheet, cell(preCompreColumn, row), preCompre); err != nil {
+			return err
+		}
+		totalFormula := fmt.Sprintf("%s+%s", cell(preCompreColumn, row), cell(layout.Compre, row))
+		if err := f.SetCellFormula(sheet, cell(layout.Total, row), totalFormula); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range []struct {
+		idx  int
+		comp component
+	}{{layout.Quiz, quiz}, {layout.MidSem, midSem}, {layout.LabTest, labTest}, {layout.WeeklyLabs, weekly}, {layout.Compre, compre}} {
+		dv := excelize.NewDataValidation(true)
+		dv.Sqref = fmt.Sprintf("%s2:%s%d", colName(c.idx), colName(c.idx), templateRows+1)
+		if err := dv.SetRange(0, c.comp.max, excelize.DataValidationTypeDecimal, excelize.DataValidationOperatorBetween); err != nil {
+			return err
+		}
+		dv.SetError(excelize.DataValidationErrorStyleStop, "Invalid mark", fmt.Sprintf("%s must be between 0 and %g", c.comp.label, c.comp.max))
+		if err := f.AddDataValidation(sheet, dv); err != nil {
+			return err
+		}
+	}
+
+	return f.SaveAs(path)
+}
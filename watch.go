@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// How long a dropped file's size and modification time must stay unchanged before it is
+// treated as fully written
+const watchSettleTime = 2 * time.Second
+
+// Subdirectory of the watched directory that inputs are moved to once processed
+const processedDirName = "processed"
+
+// Processes every .xlsx or .ods file that appears in dir, writing a JSON summary per file into
+// outDir and moving the input into dir/processed. Blocks until the watcher fails.
+func watchDir(dir, outDir string) error {
+	processedDir := filepath.Join(dir, processedDirName)
+	for _, d := range []string{outDir, processedDir} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			return err
+		}
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	if err := watcher.Add(dir); err != nil {
+		return err
+	}
+	slog.Info(fmt.Sprintf("Watching %s for new .xlsx and .ods files; reports go to %s", dir, outDir), "dir", dir, "out", outDir)
+
+	base := saveRunState()
+	pending := make(map[string]bool)
+	ready := make(chan string)
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			name := event.Name
+			if !isWorkbookDrop(name) || pending[name] {
+				continue
+			}
+			pending[name] = true
+			go func() {
+				waitForStableFile(name)
+				ready <- name
+			}()
+		case name := <-ready:
+			delete(pending, name)
+			if _, err := os.Stat(name); err != nil {
+				continue
+			}
+			if err := processDroppedFile(name, outDir, processedDir, base); err != nil {
+				slog.Error("Failed to process file", "file", name, "error", err)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// Reports whether name is an .xlsx or .ods workbook rather than a temporary or lock file
+func isWorkbookDrop(name string) bool {
+	base := filepath.Base(name)
+	isWorkbook := strings.EqualFold(filepath.Ext(base), ".xlsx") || isODS(base)
+	return isWorkbook && !strings.HasPrefix(base, "~$") && !strings.HasPrefix(base, ".")
+}
+
+// Blocks until the file's size and modification time stop changing, so that a copy still
+// in progress is not read half-written
+func waitForStableFile(name string) {
+	var lastSize int64 = -1
+	var lastMod time.Time
+	for {
+		info, err := os.Stat(name)
+		if err != nil {
+			return
+		}
+		if info.Size() == lastSize && info.ModTime().Equal(lastMod) {
+			return
+		}
+		lastSize, lastMod = info.Size(), info.ModTime()
+		time.Sleep(watchSettleTime)
+	}
+}
+
+// Processes one dropped workbook starting from the base state, so that nothing read from
+// earlier drops carries over, writes its summary report and moves it out of the way
+func processDroppedFile(name, outDir, processedDir string, base runState) error {
+	base.restore()
+	if err := prepareLayout(name, base.columns); err != nil {
+		return err
+	}
+	started := time.Now()
+	results, err := processFile(name)
+	if err != nil {
+		return err
+	}
+	observeProcessing(results, time.Since(started))
+	stem := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	reportPath := filepath.Join(outDir, stem+".json")
+	if err := writeJSONFile(reportPath, buildSummary(results)); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	if err := os.Rename(name, filepath.Join(processedDir, filepath.Base(name))); err != nil {
+		return fmt.Errorf("move to %s: %w", processedDir, err)
+	}
+	slog.Info(fmt.Sprintf("Processed %s: %d students, report written to %s", filepath.Base(name), results.TotalCount, reportPath),
+		"file", name, "students", results.TotalCount, "report", reportPath)
+	return nil
+}
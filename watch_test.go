@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestProcessDroppedFileStartsFresh(t *testing.T) {
+	resetState(t)
+	dir := t.TempDir()
+	outDir, processedDir := filepath.Join(dir, "reports"), filepath.Join(dir, "processed")
+	for _, d := range []string{outDir, processedDir} {
+		if err := os.Mkdir(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	drop := func(name string, rows ...[]any) string {
+		path := filepath.Join(dir, name)
+		if err := os.Rename(writeFixture(t, rows...), path); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	base := saveRunState()
+
+	discrepant := drop("first.xlsx", []any{1, 1, "1001", "2024A7PS0001", 25, 60, 50, 25, 160, 90, 300})
+	if err := processDroppedFile(discrepant, outDir, processedDir, base); err != nil {
+		t.Fatal(err)
+	}
+	clean := drop("second.xlsx", fixtureRow("1002", "2024A7PS0002", 20, 55, 45, 20, 80))
+	if err := processDroppedFile(clean, outDir, processedDir, base); err != nil {
+		t.Fatal(err)
+	}
+
+	if errs := findingMessages(levelError); slices.ContainsFunc(errs, func(m string) bool { return strings.Contains(m, "1001") }) {
+		t.Errorf("findings after the second drop still report the first: %q", errs)
+	}
+	for _, name := range []string{"first.json", "second.json"} {
+		if _, err := os.Stat(filepath.Join(outDir, name)); err != nil {
+			t.Errorf("report %s: %v", name, err)
+		}
+	}
+}
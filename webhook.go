@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// Body POSTed to --webhook when processing completes
+type WebhookPayload struct {
+	File     string    `json:"file"`
+	Students int       `json:"students"`
+	Skipped  int       `json:"skipped"`
+	Errors   []Finding `json:"errors"`
+	Summary  Summary   `json:"summary"`
+}
+
+// Sends the run summary to the webhook, logging rather than failing on delivery errors
+func notifyWebhook(url, filePath string, results Results) {
+	errors := findingsWithLevel(levelError)
+	if *webhookOnFlag == "error" && len(errors) == 0 {
+		return
+	}
+
+	payload := WebhookPayload{
+		File:     filePath,
+		Students: results.TotalCount,
+		Skipped:  results.Skipped(),
+		Errors:   errors,
+		Summary:  buildSummary(results),
+	}
+	if err := postJSON(url, payload); err != nil {
+		slog.Error("Failed to deliver webhook", "error", err)
+	}
+}
+
+// POSTs v as JSON and checks for a successful response
+func postJSON(url string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	client := http.Client{Timeout: *timeoutFlag}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}